@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"embed"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// builtinSourceName is the template source the embedded starter templates are
+// referenced by, EG: builtin:release-checklist
+const builtinSourceName = "builtin"
+
+//go:embed templates/*.yaml
+var builtinTemplates embed.FS
+
+func builtinRoot() templateRoot {
+	fsys, _ := fs.Sub(builtinTemplates, "templates")
+	return templateRoot{name: builtinSourceName, fsys: fsys}
+}
+
+// builtinTemplatePath maps a builtin template name to its file, the .yaml
+// extension is optional.
+func builtinTemplatePath(name string) string {
+	if path.Ext(name) == "" {
+		return name + ".yaml"
+	}
+	return name
+}
+
+// builtinTemplateNames lists the builtin templates without their extension.
+func builtinTemplateNames() []string {
+	var names []string
+	builtinRoot().walk(func(templatePath string) {
+		names = append(names, strings.TrimSuffix(templatePath, path.Ext(templatePath)))
+	})
+	return names
+}
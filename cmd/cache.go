@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the Jira metadata cache",
+	Long: `Manage the Jira metadata cache.
+
+Fields, projects, issue types, create metadata and user lookups are cached per
+Jira url and user in the user cache directory. Create metadata and user
+lookups are kept for 1h, everything else for cache_ttl (24h by default).
+cache_ttls sets the time of one kind of metadata:
+
+  cache_ttls:
+    createmeta: 10m
+    users: 4h
+
+The kinds are fields, projects, project, project-style, server-info,
+createmeta and users. Use --no-cache to bypass the cache for a command.`,
+}
+
+var cacheRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Reload the metadata of the current profile",
+	Run: func(cmd *cobra.Command, args []string) {
+		cache := openMetaCache()
+		if err := os.RemoveAll(cache.dir); err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		jiraClient := newJiraClient()
+		isJiraCloud(jiraClient)
+		getFields(jiraClient)
+		getProjects(jiraClient)
+		if projectKey := viper.GetString("project"); projectKey != "" {
+			getProject(jiraClient, projectKey)
+			isTeamManaged(jiraClient, projectKey)
+			if _, err := getCreateMeta(jiraClient, projectKey); err != nil {
+				log.Printf("Unable to cache the create metadata of %s: %v", projectKey, err)
+			}
+		}
+		fmt.Printf("Refreshed %s\n", cache.dir)
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove the cached metadata",
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := openMetaCache().dir
+		if all, _ := cmd.Flags().GetBool("all"); all {
+			dir = filepath.Dir(dir)
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			log.Fatalf("%v", err)
+		}
+		fmt.Printf("Removed %s\n", dir)
+	},
+}
+
+const defaultCacheTTL = 24 * time.Hour
+
+// kindCacheTTLs are the default times of the metadata that changes more
+// often than cache_ttl.
+var kindCacheTTLs = map[string]time.Duration{
+	"createmeta": time.Hour,
+	"users":      time.Hour,
+}
+
+// metaCache stores Jira metadata as JSON files, one directory per profile.
+type metaCache struct {
+	dir        string
+	defaultTTL time.Duration
+	disabled   bool
+}
+
+type cacheEntry struct {
+	Fetched time.Time       `json:"fetched"`
+	Data    json.RawMessage `json:"data"`
+}
+
+var cacheKeyRe = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// openMetaCache returns the cache of the configured Jira url and user.
+func openMetaCache() *metaCache {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+
+	profile := viper.GetString("baseurl")
+	if u, err := url.Parse(profile); err == nil && u.Host != "" {
+		profile = u.Host + u.Path
+	}
+	profile = cacheKeyRe.ReplaceAllString(profile+"_"+viper.GetString("username"), "_")
+
+	ttl := viper.GetDuration("cache_ttl")
+	if ttl == 0 {
+		ttl = defaultCacheTTL
+	}
+
+	return &metaCache{
+		dir:        filepath.Join(cacheDir, "gojitzu", "meta", profile),
+		defaultTTL: ttl,
+		disabled:   viper.GetBool("no_cache") || ttl < 0,
+	}
+}
+
+// ttl returns how long metadata of a kind is kept, cache_ttls.KIND first.
+func (c *metaCache) ttl(kind string) time.Duration {
+	if ttl := viper.GetDuration("cache_ttls." + kind); ttl != 0 {
+		return ttl
+	}
+	if ttl, ok := kindCacheTTLs[kind]; ok {
+		return ttl
+	}
+	return c.defaultTTL
+}
+
+// cacheKey names the entry of a kind of metadata, id tells apart the entries
+// of kinds such as project.
+func cacheKey(kind string, id string) string {
+	if id == "" {
+		return kind
+	}
+	return kind + "-" + id
+}
+
+func (c *metaCache) path(kind string, id string) string {
+	return filepath.Join(c.dir, cacheKeyRe.ReplaceAllString(cacheKey(kind, id), "_")+".json")
+}
+
+// get decodes a cached value into v, reporting false when it is missing or
+// expired.
+func (c *metaCache) get(kind string, id string, v interface{}) bool {
+	ttl := c.ttl(kind)
+	if c.disabled || ttl < 0 {
+		return false
+	}
+	data, err := os.ReadFile(c.path(kind, id))
+	if err != nil {
+		return false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || time.Since(entry.Fetched) > ttl {
+		return false
+	}
+	return json.Unmarshal(entry.Data, v) == nil
+}
+
+// put stores a value. The cache is best effort, failures are ignored.
+func (c *metaCache) put(kind string, id string, v interface{}) {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(cacheEntry{Fetched: time.Now(), Data: value})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return
+	}
+	os.WriteFile(c.path(kind, id), data, 0600)
+}
+
+// cached fills v from the cache, or with fetch and stores the result.
+func cached(kind string, id string, v interface{}, fetch func() error) error {
+	cache := openMetaCache()
+	if cache.get(kind, id, v) {
+		return nil
+	}
+	if err := fetch(); err != nil {
+		return err
+	}
+	cache.put(kind, id, v)
+	return nil
+}
+
+// getFields returns the fields of the server.
+func getFields(jiraClient *jira.Client) []jira.Field {
+	var fields []jira.Field
+	cached("fields", "", &fields, func() error {
+		list, resp, err := jiraClient.Field.GetList()
+		checkJiraError(resp, err)
+		fields = list
+		return nil
+	})
+	return fields
+}
+
+// getProject returns a project with its issue types and components.
+func getProject(jiraClient *jira.Client, projectKey string) *jira.Project {
+	var project *jira.Project
+	cached("project", projectKey, &project, func() error {
+		p, resp, err := jiraClient.Project.Get(projectKey)
+		checkJiraError(resp, err)
+		project = p
+		return nil
+	})
+	return project
+}
+
+// getProjects returns the projects the user can see.
+func getProjects(jiraClient *jira.Client) jira.ProjectList {
+	var projects jira.ProjectList
+	cached("projects", "", &projects, func() error {
+		list, resp, err := jiraClient.Project.GetList()
+		checkJiraError(resp, err)
+		projects = *list
+		return nil
+	})
+	return projects
+}
+
+// completeProjects completes project keys from the cached project list.
+func completeProjects(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var projects jira.ProjectList
+	if !openMetaCache().get("projects", "", &projects) {
+		if viper.GetString("baseurl") == "" {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		projects = getProjects(newJiraClient())
+	}
+
+	var keys []string
+	for _, project := range projects {
+		keys = append(keys, fmt.Sprintf("%s\t%s", project.Key, project.Name))
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeIssueTypes completes the issue types of the configured project.
+func completeIssueTypes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	projectKey := viper.GetString("project")
+	if projectKey == "" || viper.GetString("baseurl") == "" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var types []string
+	for _, issueType := range getProject(newJiraClient(), projectKey).IssueTypes {
+		types = append(types, issueType.Name)
+	}
+	return types, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	RootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheRefreshCmd, cacheClearCmd)
+	cacheClearCmd.Flags().Bool("all", false, "remove the cache of every profile")
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/spf13/viper"
+)
+
+// newJiraClient creates a Jira client using the configured base url and credentials.
+func newJiraClient() *jira.Client {
+	base := viper.GetString("baseurl")
+	username := viper.GetString("username")
+	password := viper.GetString("password")
+
+	tp := jira.BasicAuthTransport{
+		Username: username,
+		Password: password,
+	}
+
+	jiraClient, err := jira.NewClient(tp.Client(), base)
+	if err != nil {
+		panic(err)
+	}
+
+	return jiraClient
+}
+
+// checkJiraError prints the body of a failed Jira response and panics.
+func checkJiraError(resp *jira.Response, err error) {
+	if err == nil {
+		return
+	}
+
+	if resp != nil && resp.Body != nil {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Println(string(body))
+	}
+	panic(err)
+}
+
+// browseURL returns the web url of an issue.
+func browseURL(issueKey string) string {
+	return fmt.Sprintf("%s/browse/%s", strings.TrimRight(viper.GetString("baseurl"), "/"), issueKey)
+}
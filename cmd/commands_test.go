@@ -0,0 +1,431 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/defektive/gojitzu/internal/jiratest"
+	"github.com/defektive/gojitzu/pkg/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// runCommand runs gojitzu against the fake server with a config for project
+// OPS and returns what it printed.
+func runCommand(t *testing.T, s *jiratest.Server, args ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+	configFile := filepath.Join(dir, "gojitzu.yaml")
+	configData := fmt.Sprintf("baseurl: %s\nusername: user\npassword: token\nproject: OPS\n", s.URL)
+	if err := os.WriteFile(configFile, []byte(configData), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// commands and flags are package globals, start every run from scratch
+	resetFlags(RootCmd)
+	Config = config.ConfigMap{}
+	cfgFile = ""
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+
+	var runErr error
+	func() {
+		defer func() {
+			if p := recover(); p != nil {
+				runErr = fmt.Errorf("panic: %v", p)
+			}
+		}()
+		RootCmd.SetArgs(append([]string{"--config", configFile}, args...))
+		runErr = RootCmd.Execute()
+	}()
+
+	w.Close()
+	os.Stdout = stdout
+	out := <-output
+	if runErr != nil {
+		t.Fatalf("gojitzu %s: %v\n%s%s", strings.Join(args, " "), runErr, out, logs.String())
+	}
+	return out
+}
+
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			var values []string
+			if def := strings.Trim(f.DefValue, "[]"); def != "" {
+				values = strings.Split(def, ",")
+			}
+			slice.Replace(values)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+func newTestServer(t *testing.T) *jiratest.Server {
+	s := jiratest.NewServer(t)
+	s.AddProject(jira.Project{Key: "OPS", Name: "Operations"})
+	s.AddField(jiratest.ClassicEpicFields...)
+	return s
+}
+
+func TestProjectsCommand(t *testing.T) {
+	s := newTestServer(t)
+	s.AddProject(jira.Project{Key: "DEV", Name: "Development"})
+
+	var projects jira.ProjectList
+	if err := json.Unmarshal([]byte(runCommand(t, s, "projects")), &projects); err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 2 || projects[0].Key != "OPS" || projects[1].Name != "Development" {
+		t.Errorf("unexpected projects %+v", projects)
+	}
+}
+
+func TestIssuesCommand(t *testing.T) {
+	s := newTestServer(t)
+	s.AddProject(jira.Project{Key: "DEV"})
+	s.PageSize = 2
+	for _, key := range []string{"OPS", "OPS", "DEV", "OPS"} {
+		s.AddIssue(jira.Issue{Fields: &jira.IssueFields{
+			Project: jira.Project{Key: key},
+			Type:    jira.IssueType{Name: "Task"},
+			Summary: "A " + key + " task",
+		}})
+	}
+
+	var issues []jira.Issue
+	if err := json.Unmarshal([]byte(runCommand(t, s, "issues", "--jql", "project = OPS")), &issues); err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 3 {
+		t.Fatalf("got %d issues, want 3", len(issues))
+	}
+	if pages := len(s.RequestsTo("GET", "/rest/api/2/search/jql")); pages != 2 {
+		t.Errorf("searched %d pages, want 2", pages)
+	}
+}
+
+func TestAddIssueCommand(t *testing.T) {
+	s := newTestServer(t)
+
+	out := runCommand(t, s, "issues", "addIssue", "-s", "Broken build", "-t", "bug", "-l", "ci,urgent")
+	if !strings.Contains(out, "OPS-1") {
+		t.Errorf("unexpected output %q", out)
+	}
+	runCommand(t, s, "issues", "addIssue", "-s", "Fix the build", "-t", "sub-task", "--parent", "OPS-1")
+
+	s.AssertCreated(t, "Broken build", "Fix the build")
+	bug := s.RequireIssue(t, "OPS-1")
+	if bug.Fields.Type.Name != "Bug" || strings.Join(bug.Fields.Labels, ",") != "ci,urgent" {
+		t.Errorf("unexpected bug %+v", bug.Fields)
+	}
+	if len(bug.Fields.Subtasks) != 1 || bug.Fields.Subtasks[0].Key != "OPS-2" {
+		t.Errorf("unexpected sub-tasks %+v", bug.Fields.Subtasks)
+	}
+}
+
+func TestTplCreatesEpic(t *testing.T) {
+	s := newTestServer(t)
+
+	out := runCommand(t, s, "tpl", "-t", "builtin:release-checklist", "--title", "Release 2.0", "--var", "release=2.0")
+	if !strings.Contains(out, "Done OPS-1") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	epic := s.RequireCreated(t, "Release 2.0")
+	if epic.Fields.Type.Name != "Epic" || epic.Fields.Unknowns["customfield_10011"] != "Release 2.0" {
+		t.Errorf("epic has type %s and Epic Name %v", epic.Fields.Type.Name, epic.Fields.Unknowns["customfield_10011"])
+	}
+
+	freeze := s.RequireCreated(t, "Code freeze for 2.0")
+	if freeze.Fields.Unknowns["customfield_10014"] != epic.Key {
+		t.Errorf("task Epic Link is %v, want %s", freeze.Fields.Unknowns["customfield_10014"], epic.Key)
+	}
+	if len(freeze.Fields.Subtasks) != 2 {
+		t.Errorf("got %d sub-tasks, want 2", len(freeze.Fields.Subtasks))
+	}
+	for _, issue := range s.Created() {
+		if issue.Fields.Summary == "Review database migrations" {
+			t.Errorf("created the task of a false when:")
+		}
+	}
+
+	s.AssertLinked(t, "Blocks", s.RequireCreated(t, "QA sign off for 2.0").Key, s.RequireCreated(t, "Ship 2.0").Key)
+	s.AssertRequested(t, "GET", "/rest/api/2/issue/createmeta/OPS/issuetypes")
+}
+
+func TestTplEpicNameOffScreen(t *testing.T) {
+	s := newTestServer(t)
+	s.HideField("Epic", "customfield_10011")
+
+	runCommand(t, s, "tpl", "-t", "builtin:release-checklist", "-T", "Release 2.0", "--var", "release=2.0")
+
+	epic := s.RequireCreated(t, "Release 2.0")
+	if _, ok := epic.Fields.Unknowns["customfield_10011"]; ok {
+		t.Errorf("epic sets the Epic Name the create screen does not have")
+	}
+	if task := s.RequireCreated(t, "Ship 2.0"); task.Fields.Unknowns["customfield_10014"] != epic.Key {
+		t.Errorf("task Epic Link is %v, want %s", task.Fields.Unknowns["customfield_10014"], epic.Key)
+	}
+}
+
+func TestTplTeamManagedEpic(t *testing.T) {
+	s := jiratest.NewServer(t)
+	s.AddTeamManagedProject(jira.Project{Key: "OPS"})
+	epic, err := s.AddIssue(jira.Issue{Fields: &jira.IssueFields{
+		Project: jira.Project{Key: "OPS"},
+		Type:    jira.IssueType{Name: "Epic"},
+		Summary: "Onboarding",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	template := `version: "2"
+tasks:
+  - title: Laptop for {{.who}}
+    type: bug
+    subtasks:
+      - title: Install the tools
+  - title: Accounts for {{.who}}
+    labels: [it]
+    description: |-
+      Ask {{.who}} to run {{kubectl get pods}} once the accounts work.
+`
+	if err := os.WriteFile(filepath.Join(dir, "hire.yaml"), []byte(template), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	runCommand(t, s, "tpl", "-I", dir, "-t", "hire.yaml", "-e", epic.Key, "--var", "who=Sam")
+
+	s.AssertCreated(t, "Laptop for Sam", "Install the tools", "Accounts for Sam")
+	laptop := s.RequireCreated(t, "Laptop for Sam")
+	if laptop.Fields.Parent == nil || laptop.Fields.Parent.Key != epic.Key || laptop.Fields.Type.Name != "Bug" {
+		t.Errorf("unexpected task %+v", laptop.Fields)
+	}
+	if sub := s.RequireCreated(t, "Install the tools"); sub.Fields.Parent.Key != laptop.Key {
+		t.Errorf("sub-task parent is %s, want %s", sub.Fields.Parent.Key, laptop.Key)
+	}
+
+	// Jira {{monospace}} markup is kept, only template actions are expanded
+	want := "Ask Sam to run {{kubectl get pods}} once the accounts work."
+	if accounts := s.RequireCreated(t, "Accounts for Sam"); accounts.Fields.Description != want {
+		t.Errorf("description is %q, want %q", accounts.Fields.Description, want)
+	}
+}
+
+func TestTplDryRun(t *testing.T) {
+	s := newTestServer(t)
+
+	out := runCommand(t, s, "tpl", "-t", "builtin:release-checklist", "-T", "Release", "--dry-run")
+	if !strings.Contains(out, "Ship 1.0.0") {
+		t.Errorf("plan does not list the tasks: %q", out)
+	}
+	if len(s.Requests()) != 0 {
+		t.Errorf("dry run sent %d requests", len(s.Requests()))
+	}
+
+	// the epic title is only needed to create issues
+	out = runCommand(t, s, "tpl", "-t", "builtin:release-checklist", "--dry-run")
+	if !strings.Contains(out, "Ship 1.0.0") {
+		t.Errorf("plan without --title does not list the tasks: %q", out)
+	}
+}
+
+func TestCheckTemplateAttachments(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "runbook.md"), []byte("# Runbook"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks := []Task{{
+		Title:       "Prepare",
+		Attachments: []string{"runbook.md", "missing.md"},
+		templateDir: dir,
+		SubTasks:    []SubTask{{Title: "Check", Attachments: []string{"gone.txt"}, templateDir: dir}},
+	}}
+	err := checkTemplateAttachments(tasks)
+	if err == nil || !strings.Contains(err.Error(), "missing.md") || !strings.Contains(err.Error(), "gone.txt") || strings.Contains(err.Error(), "runbook.md") {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	tasks[0].Attachments = tasks[0].Attachments[:1]
+	tasks[0].SubTasks = nil
+	if err := checkTemplateAttachments(tasks); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestEpicSpecCheck(t *testing.T) {
+	fields := epicFields{name: "customfield_10011", color: "customfield_10017"}
+	for _, color := range []string{"", "3", "14", "ghx-label-7", "GHX-LABEL-1"} {
+		spec := EpicSpec{Title: "Onboard", Color: color}
+		if problems := spec.check(fields); len(problems) > 0 {
+			t.Errorf("color %q: unexpected problems %v", color, problems)
+		}
+	}
+
+	for _, spec := range []EpicSpec{
+		{Title: "Onboard", Color: "15"},
+		{Title: "Onboard", Color: "red"},
+		{Title: strings.Repeat("x", 256)},
+	} {
+		if problems := spec.check(fields); len(problems) != 1 {
+			t.Errorf("%+v: expected one problem, got %v", spec, problems)
+		}
+	}
+
+	spec := EpicSpec{Title: "Onboard", Color: "3"}
+	if problems := spec.check(epicFields{}); len(problems) != 1 {
+		t.Errorf("expected the missing colour field to be reported, got %v", problems)
+	}
+}
+
+func TestUserSearchCache(t *testing.T) {
+	s := newTestServer(t)
+	s.AddUser(jira.User{Name: "user", EmailAddress: "user@example.com"})
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(t.TempDir(), "cache"))
+	viper.Set("baseurl", s.URL)
+	defer viper.Set("baseurl", "")
+
+	jiraClient, err := jira.NewClient(nil, s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := newUserResolver(jiraClient, "OPS")
+
+	// a miss is not cached, the user is found once added
+	if _, err := resolver.search("a@b.c"); err == nil {
+		t.Fatal("expected a@b.c to be missing")
+	}
+	s.AddUser(jira.User{Name: "ab", EmailAddress: "a@b.c"}, jira.User{Name: "ab2", EmailAddress: "a_b.c"})
+	user, err := resolver.search("a@b.c")
+	if err != nil || user.Name != "ab" {
+		t.Fatalf("unexpected user %v, %v", user, err)
+	}
+
+	// identifiers that only differ in characters not allowed in file names
+	// have their own entries
+	user, err = resolver.search("a_b.c")
+	if err != nil || user.Name != "ab2" {
+		t.Fatalf("unexpected user %v, %v", user, err)
+	}
+
+	resolver.search("a@b.c")
+	if n := len(s.RequestsTo("GET", "/rest/api/2/user/search")); n != 3 {
+		t.Errorf("expected 3 user searches, got %d", n)
+	}
+}
+
+func TestMetaCacheTTL(t *testing.T) {
+	viper.Set("cache_ttls", map[string]interface{}{"createmeta": "10m"})
+	defer viper.Set("cache_ttls", nil)
+
+	cache := &metaCache{defaultTTL: defaultCacheTTL}
+	for kind, want := range map[string]time.Duration{
+		"createmeta": 10 * time.Minute,
+		"users":      time.Hour,
+		"fields":     defaultCacheTTL,
+	} {
+		if ttl := cache.ttl(kind); ttl != want {
+			t.Errorf("%s: expected %v, got %v", kind, want, ttl)
+		}
+	}
+}
+
+func TestTemplateSearchPathsWithSpaces(t *testing.T) {
+	defer viper.Set("templatepath", nil)
+
+	support := filepath.Join(t.TempDir(), "Application Support", "gojitzu")
+	team := filepath.Join(t.TempDir(), "team templates")
+	for _, value := range []interface{}{
+		support + string(os.PathListSeparator) + team,
+		[]interface{}{support, team},
+		[]string{support, team},
+	} {
+		viper.Set("templatepath", value)
+		if paths := templateSearchPaths(); len(paths) != 2 || paths[0] != support || paths[1] != team {
+			t.Errorf("%#v: got paths %q", value, paths)
+		}
+	}
+}
+
+func TestUserSearchNeedsExactMatch(t *testing.T) {
+	s := newTestServer(t)
+	s.AddUser(jira.User{Name: "user"}, jira.User{Name: "bobby.tables", EmailAddress: "bobby@example.com"})
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(t.TempDir(), "cache"))
+	viper.Set("baseurl", s.URL)
+	defer viper.Set("baseurl", "")
+
+	jiraClient, err := jira.NewClient(nil, s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := newUserResolver(jiraClient, "OPS")
+
+	_, err = resolver.search("bob")
+	if err == nil || !strings.Contains(err.Error(), `no exact match for "bob" (did you mean bobby@example.com?)`) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if user, err := resolver.search("bobby.tables"); err != nil || user.Name != "bobby.tables" {
+		t.Errorf("unexpected user %v, %v", user, err)
+	}
+}
+
+func TestGetComment(t *testing.T) {
+	s := newTestServer(t)
+	issue, err := s.AddIssue(jira.Issue{Fields: &jira.IssueFields{
+		Project:  jira.Project{Key: "OPS"},
+		Type:     jira.IssueType{Name: "Task"},
+		Summary:  "Rotate keys",
+		Comments: &jira.Comments{Comments: []*jira.Comment{{ID: "100", Body: "Rotated staging"}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jiraClient, err := jira.NewClient(nil, s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment, err := getComment(jiraClient, issue.Key, "100"); err != nil || comment.Body != "Rotated staging" {
+		t.Errorf("unexpected comment %v, %v", comment, err)
+	}
+	if _, err := getComment(jiraClient, issue.Key, "101"); err == nil || !strings.Contains(err.Error(), "comment 101 not found on "+issue.Key) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateFmt = "2006-01-02"
+
+// dateContext resolves the date expressions used by the tpl command and
+// template tasks. Expressions can be:
+//
+//	2021-06-30              an absolute date
+//	today, tomorrow         relative to the anchor date
+//	friday, next friday     the first friday after the anchor date
+//	+3d, -1w, +2m           days, weeks or months from the anchor date
+//	+1bd                    business days from the anchor date
+//	epic-2w                 relative to the epic due date
+//	in 3 business days      same as +3bd
+type dateContext struct {
+	anchor   time.Time
+	epicDue  time.Time
+	holidays map[string]bool
+}
+
+var (
+	relativeDateRe = regexp.MustCompile(`^(epic|anchor|today)?\s*([+-])\s*(\d+)\s*(bd|d|w|m)$`)
+	inDateRe       = regexp.MustCompile(`^in\s+(\d+)\s+(business\s+days?|days?|weeks?|months?)$`)
+)
+
+func newDateContext(anchor time.Time, holidays []string) (*dateContext, error) {
+	ctx := &dateContext{
+		anchor:   truncateDay(anchor),
+		holidays: map[string]bool{},
+	}
+	for _, holiday := range holidays {
+		h, err := time.ParseInLocation(dateFmt, holiday, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday %q, expected YYYY-MM-DD", holiday)
+		}
+		ctx.holidays[h.Format(dateFmt)] = true
+	}
+	return ctx, nil
+}
+
+// parse resolves a date expression.
+func (c *dateContext) parse(expr string) (time.Time, error) {
+	expr = strings.ToLower(strings.TrimSpace(expr))
+
+	if t, err := time.ParseInLocation(dateFmt, expr, time.Local); err == nil {
+		return t, nil
+	}
+
+	switch expr {
+	case "today":
+		return c.anchor, nil
+	case "tomorrow":
+		return c.anchor.AddDate(0, 0, 1), nil
+	case "epic":
+		if c.epicDue.IsZero() {
+			return time.Time{}, fmt.Errorf("%q needs an epic due date", expr)
+		}
+		return c.epicDue, nil
+	}
+
+	if weekday, ok := parseWeekday(strings.TrimPrefix(expr, "next ")); ok {
+		days := (int(weekday) - int(c.anchor.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return c.anchor.AddDate(0, 0, days), nil
+	}
+
+	if m := inDateRe.FindStringSubmatch(expr); m != nil {
+		unit := map[byte]string{'b': "bd", 'd': "d", 'w': "w", 'm': "m"}[m[2][0]]
+		expr = "+" + m[1] + unit
+	}
+
+	m := relativeDateRe.FindStringSubmatch(expr)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD, today, friday, +3d, +1bd or epic-2w", expr)
+	}
+
+	base := c.anchor
+	if m[1] == "epic" {
+		if c.epicDue.IsZero() {
+			return time.Time{}, fmt.Errorf("%q needs an epic due date", expr)
+		}
+		base = c.epicDue
+	}
+
+	n, _ := strconv.Atoi(m[3])
+	if m[2] == "-" {
+		n = -n
+	}
+
+	switch m[4] {
+	case "d":
+		return base.AddDate(0, 0, n), nil
+	case "w":
+		return base.AddDate(0, 0, 7*n), nil
+	case "m":
+		return base.AddDate(0, n, 0), nil
+	default:
+		return c.addBusinessDays(base, n), nil
+	}
+}
+
+// addBusinessDays moves n working days from t, skipping weekends and holidays.
+func (c *dateContext) addBusinessDays(t time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		t = t.AddDate(0, 0, step)
+		if c.isBusinessDay(t) {
+			n--
+		}
+	}
+	return t
+}
+
+func (c *dateContext) isBusinessDay(t time.Time) bool {
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	return !c.holidays[t.Format(dateFmt)]
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		full := strings.ToLower(d.String())
+		if name == full || name == full[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
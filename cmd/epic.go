@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/spf13/cobra"
+)
+
+// epicCmd represents the epic command
+var epicCmd = &cobra.Command{
+	Use:   "epic",
+	Short: "Work with epics",
+	Long:  `Work with epics and the issues inside them.`,
+}
+
+// epicChildrenJQL matches the issues of an epic in both classic ("Epic Link")
+// and team-managed (parent) projects.
+func epicChildrenJQL(epicKey string) string {
+	return fmt.Sprintf(`parent = %[1]s OR "Epic Link" = %[1]s`, epicKey)
+}
+
+// getEpicIssues returns the epic, its children and their sub-tasks.
+func getEpicIssues(jiraClient *jira.Client, epicKey string) []jira.Issue {
+	epic, resp, err := jiraClient.Issue.Get(epicKey, nil)
+	checkJiraError(resp, err)
+
+	issues := []jira.Issue{*epic}
+	seen := map[string]bool{epic.Key: true}
+	for _, child := range GetAllIssues(jiraClient, epicChildrenJQL(epicKey)) {
+		if seen[child.Key] {
+			continue
+		}
+		seen[child.Key] = true
+		issues = append(issues, child)
+
+		for _, sub := range child.Fields.Subtasks {
+			if seen[sub.Key] {
+				continue
+			}
+			seen[sub.Key] = true
+			issues = append(issues, jira.Issue{ID: sub.ID, Key: sub.Key, Fields: &sub.Fields})
+		}
+	}
+
+	return issues
+}
+
+func init() {
+	RootCmd.AddCommand(epicCmd)
+}
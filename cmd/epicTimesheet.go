@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// epicTimesheetCmd represents the epic timesheet command
+var epicTimesheetCmd = &cobra.Command{
+	Use:   "timesheet EPIC",
+	Short: "Report time logged against an epic",
+	Long: `Report time logged against an epic, its issues and their sub-tasks,
+per user and per day.
+
+  gojitzu epic timesheet EPIC-1 --from 2021-06-01 --to 2021-06-30 --output csv > june.csv`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		output, _ := cmd.Flags().GetString("output")
+
+		if _, err := time.Parse(dateFmt, from); from != "" && err != nil {
+			log.Fatalf("Invalid --from %q, expected YYYY-MM-DD", from)
+		}
+		if _, err := time.Parse(dateFmt, to); to != "" && err != nil {
+			log.Fatalf("Invalid --to %q, expected YYYY-MM-DD", to)
+		}
+
+		jiraClient := newJiraClient()
+
+		type entryKey struct {
+			day  string
+			user string
+		}
+		seconds := map[entryKey]int{}
+
+		for _, issue := range getEpicIssues(jiraClient, args[0]) {
+			worklog, resp, err := jiraClient.Issue.GetWorklogs(issue.Key)
+			checkJiraError(resp, err)
+
+			for _, w := range worklog.Worklogs {
+				if w.Started == nil {
+					continue
+				}
+				started := time.Time(*w.Started).Local()
+				day := started.Format(dateFmt)
+				if (from != "" && day < from) || (to != "" && day > to) {
+					continue
+				}
+				seconds[entryKey{day, userDisplayName(w.Author)}] += w.TimeSpentSeconds
+			}
+		}
+
+		keys := make([]entryKey, 0, len(seconds))
+		for k := range seconds {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].day != keys[j].day {
+				return keys[i].day < keys[j].day
+			}
+			return keys[i].user < keys[j].user
+		})
+
+		hours := func(s int) string {
+			return strconv.FormatFloat(float64(s)/3600, 'f', 2, 64)
+		}
+
+		switch output {
+		case "csv":
+			w := csv.NewWriter(os.Stdout)
+			w.Write([]string{"date", "user", "hours"})
+			for _, k := range keys {
+				w.Write([]string{k.day, k.user, hours(seconds[k])})
+			}
+			w.Flush()
+			if err := w.Error(); err != nil {
+				panic(err)
+			}
+		case "text":
+			perUser := map[string]int{}
+			total := 0
+			for _, k := range keys {
+				fmt.Printf("%-10s  %-30s %8s\n", k.day, k.user, hours(seconds[k]))
+				perUser[k.user] += seconds[k]
+				total += seconds[k]
+			}
+
+			users := make([]string, 0, len(perUser))
+			for user := range perUser {
+				users = append(users, user)
+			}
+			sort.Strings(users)
+
+			fmt.Println()
+			for _, user := range users {
+				fmt.Printf("%-10s  %-30s %8s\n", "total", user, hours(perUser[user]))
+			}
+			fmt.Printf("%-10s  %-30s %8s\n", "total", "", hours(total))
+		default:
+			log.Fatalf("Unknown output format %q, expected text or csv", output)
+		}
+	},
+}
+
+func init() {
+	epicCmd.AddCommand(epicTimesheetCmd)
+	epicTimesheetCmd.Flags().String("from", "", "First day to include, YYYY-MM-DD")
+	epicTimesheetCmd.Flags().String("to", "", "Last day to include, YYYY-MM-DD")
+	epicTimesheetCmd.Flags().StringP("output", "o", "text", "Output format: text or csv")
+}
@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// evalCondition evaluates a when: expression against the run variables.
+//
+// Expressions support variables, string and number literals, true and false,
+// the comparisons == != < <= > >=, the boolean operators && || ! and
+// parentheses, EG: env == "prod" && (has_mobile || platform != "web").
+// Undefined variables are false, as are the strings "", "false", "no" and "0".
+func evalCondition(expr string, vars map[string]interface{}) (bool, error) {
+	tokens, err := tokenizeExpr(expr)
+	if err != nil {
+		return false, err
+	}
+
+	p := &exprParser{tokens: tokens, vars: vars}
+	value, err := p.parseOr()
+	if err != nil {
+		return false, err
+	}
+	if p.pos < len(p.tokens) {
+		return false, fmt.Errorf("unexpected %q in %q", p.tokens[p.pos].text, expr)
+	}
+	return truthy(value), nil
+}
+
+type exprTokenKind int
+
+const (
+	tokIdent exprTokenKind = iota
+	tokString
+	tokNumber
+	tokOp
+)
+
+type exprToken struct {
+	kind exprTokenKind
+	text string
+}
+
+func tokenizeExpr(expr string) ([]exprToken, error) {
+	var tokens []exprToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			j := i + 1
+			var b strings.Builder
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				b.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated string in %q", expr)
+			}
+			tokens = append(tokens, exprToken{tokString, b.String()})
+			i = j + 1
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, exprToken{tokNumber, string(runes[i:j])})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_' || runes[j] == '.' || runes[j] == '-') {
+				j++
+			}
+			tokens = append(tokens, exprToken{tokIdent, string(runes[i:j])})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")"} {
+				if strings.HasPrefix(string(runes[i:]), candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q in %q", string(r), expr)
+			}
+			tokens = append(tokens, exprToken{tokOp, op})
+			i += len(op)
+		}
+	}
+	return tokens, nil
+}
+
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+	vars   map[string]interface{}
+}
+
+func (p *exprParser) peekOp(ops ...string) string {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokOp {
+		return ""
+	}
+	for _, op := range ops {
+		if p.tokens[p.pos].text == op {
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *exprParser) parseOr() (interface{}, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("||") != "" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = truthy(left) || truthy(right)
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (interface{}, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOp("&&") != "" {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = truthy(left) && truthy(right)
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseNot() (interface{}, error) {
+	if p.peekOp("!") != "" {
+		p.pos++
+		value, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return !truthy(value), nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (interface{}, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	op := p.peekOp("==", "!=", "<=", ">=", "<", ">")
+	if op == "" {
+		return left, nil
+	}
+	p.pos++
+
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	return compareValues(left, op, right)
+}
+
+func (p *exprParser) parsePrimary() (interface{}, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	switch tok.kind {
+	case tokString:
+		return tok.text, nil
+	case tokNumber:
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", tok.text)
+		}
+		return n, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return lookupVar(p.vars, tok.text), nil
+	}
+
+	if tok.text == "(" {
+		value, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peekOp(")") == "" {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("unexpected %q", tok.text)
+}
+
+// lookupVar finds a variable, following dots into maps, EG: item.host.
+func lookupVar(vars map[string]interface{}, name string) interface{} {
+	var value interface{} = vars
+	for _, part := range strings.Split(name, ".") {
+		switch m := value.(type) {
+		case map[string]interface{}:
+			value = m[part]
+		case map[interface{}]interface{}:
+			value = m[part]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+func compareValues(left interface{}, op string, right interface{}) (bool, error) {
+	ln, lok := toNumber(left)
+	rn, rok := toNumber(right)
+	if lok && rok {
+		switch op {
+		case "==":
+			return ln == rn, nil
+		case "!=":
+			return ln != rn, nil
+		case "<":
+			return ln < rn, nil
+		case "<=":
+			return ln <= rn, nil
+		case ">":
+			return ln > rn, nil
+		default:
+			return ln >= rn, nil
+		}
+	}
+
+	ls, rs := valueString(left), valueString(right)
+	switch op {
+	case "==":
+		return ls == rs, nil
+	case "!=":
+		return ls != rs, nil
+	case "<":
+		return ls < rs, nil
+	case "<=":
+		return ls <= rs, nil
+	case ">":
+		return ls > rs, nil
+	default:
+		return ls >= rs, nil
+	}
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func valueString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func truthy(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case float64:
+		return t != 0
+	case int:
+		return t != 0
+	case string:
+		switch strings.ToLower(t) {
+		case "", "false", "no", "0":
+			return false
+		}
+		return true
+	case []interface{}:
+		return len(t) > 0
+	}
+	return true
+}
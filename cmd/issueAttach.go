@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/spf13/cobra"
+)
+
+// issueAttachCmd represents the issues attach command
+var issueAttachCmd = &cobra.Command{
+	Use:   "attach KEY FILE...",
+	Short: "Upload files to an issue",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		jiraClient := newJiraClient()
+		uploadAttachments(jiraClient, args[0], "", args[1:])
+	},
+}
+
+// issueAttachmentsCmd represents the issues attachments command
+var issueAttachmentsCmd = &cobra.Command{
+	Use:   "attachments",
+	Short: "List and download issue attachments",
+}
+
+var issueAttachmentsListCmd = &cobra.Command{
+	Use:   "list KEY",
+	Short: "List attachments on an issue",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		jiraClient := newJiraClient()
+		for _, a := range getAttachments(jiraClient, args[0]) {
+			fmt.Printf("%-8s %-40s %10s  %s\n", a.ID, a.Filename, formatSize(a.Size), a.MimeType)
+		}
+	},
+}
+
+var issueAttachmentsDownloadCmd = &cobra.Command{
+	Use:   "download KEY [FILENAME...]",
+	Short: "Download attachments from an issue",
+	Long: `Download attachments from an issue into a directory.
+
+Only the named attachments are downloaded when filenames are given.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, _ := cmd.Flags().GetString("dir")
+
+		wanted := map[string]bool{}
+		for _, name := range args[1:] {
+			wanted[name] = true
+		}
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			log.Fatalf("Unable to create %s: %v", dir, err)
+		}
+
+		jiraClient := newJiraClient()
+		for _, a := range getAttachments(jiraClient, args[0]) {
+			if len(wanted) > 0 && !wanted[a.Filename] {
+				continue
+			}
+
+			resp, err := jiraClient.Issue.DownloadAttachment(a.ID)
+			checkJiraError(resp, err)
+
+			dest := filepath.Join(dir, filepath.Base(a.Filename))
+			f, err := os.Create(dest)
+			if err != nil {
+				log.Fatalf("Unable to create %s: %v", dest, err)
+			}
+			_, err = io.Copy(f, resp.Body)
+			resp.Body.Close()
+			f.Close()
+			if err != nil {
+				log.Fatalf("Unable to write %s: %v", dest, err)
+			}
+			fmt.Printf("Downloaded %s\n", dest)
+		}
+	},
+}
+
+func getAttachments(jiraClient *jira.Client, issueKey string) []*jira.Attachment {
+	issue, resp, err := jiraClient.Issue.Get(issueKey, &jira.GetQueryOptions{Fields: "attachment"})
+	checkJiraError(resp, err)
+	return issue.Fields.Attachments
+}
+
+// attachmentPath resolves a relative attachment path against baseDir, which
+// is the template directory for template attachments.
+func attachmentPath(baseDir string, path string) string {
+	if baseDir != "" && !filepath.IsAbs(path) {
+		return filepath.Join(baseDir, path)
+	}
+	return path
+}
+
+// checkTemplateAttachments makes sure every template attachment can be read,
+// so a run does not stop after some of its issues were created.
+func checkTemplateAttachments(tasks []Task) error {
+	var problems []string
+	check := func(title string, baseDir string, paths []string) {
+		for _, path := range paths {
+			fullPath := attachmentPath(baseDir, path)
+			f, err := os.Open(fullPath)
+			if err == nil {
+				var fi os.FileInfo
+				if fi, err = f.Stat(); err == nil && fi.IsDir() {
+					err = fmt.Errorf("is a directory")
+				}
+				f.Close()
+			}
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: attachment %s: %v", title, fullPath, err))
+			}
+		}
+	}
+
+	for _, task := range tasks {
+		check(task.Title, task.templateDir, task.Attachments)
+		for _, subTask := range task.SubTasks {
+			check(subTask.Title, subTask.templateDir, subTask.Attachments)
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("unable to read attachments:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// uploadAttachments uploads files to an issue. Relative paths are resolved
+// with attachmentPath.
+func uploadAttachments(jiraClient *jira.Client, issueKey string, baseDir string, paths []string) {
+	for _, path := range paths {
+		fullPath := attachmentPath(baseDir, path)
+
+		f, err := os.Open(fullPath)
+		if err != nil {
+			log.Fatalf("Unable to open attachment %s: %v", fullPath, err)
+		}
+
+		_, resp, err := jiraClient.Issue.PostAttachment(issueKey, f, filepath.Base(fullPath))
+		f.Close()
+		checkJiraError(resp, err)
+		fmt.Printf("Attached %s to %s\n", filepath.Base(fullPath), issueKey)
+	}
+}
+
+func init() {
+	issuesCmd.AddCommand(issueAttachCmd)
+	issuesCmd.AddCommand(issueAttachmentsCmd)
+	issueAttachmentsCmd.AddCommand(issueAttachmentsListCmd, issueAttachmentsDownloadCmd)
+	issueAttachmentsDownloadCmd.Flags().StringP("dir", "d", ".", "Directory to download into")
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/spf13/cobra"
+)
+
+// issueCommentCmd represents the issues comment command
+var issueCommentCmd = &cobra.Command{
+	Use:   "comment",
+	Short: "Manage issue comments",
+	Long: `Add, list, edit and delete issue comments.
+
+Comment bodies come from --body, from --file (- for stdin) or, when neither
+is given, from $EDITOR.`,
+}
+
+var issueCommentAddCmd = &cobra.Command{
+	Use:   "add [KEY]",
+	Short: "Add a comment",
+	Long: `Add a comment to an issue, or with --jql to every matching issue.
+
+  gojitzu issues comment add PROJ-1 --body "Investigating"
+  gojitzu issues comment add --jql "labels = incident-42" --file status.txt`,
+	Args: cobra.RangeArgs(0, 1),
+	Run: func(cmd *cobra.Command, args []string) {
+		jql, _ := cmd.Flags().GetString("jql")
+		if (jql == "") == (len(args) == 0) {
+			log.Fatalf("Expected either an issue KEY or --jql")
+		}
+
+		body := commentBody(cmd, "")
+		visibility := commentVisibility(cmd)
+		jiraClient := newJiraClient()
+
+		issueKeys := args
+		if jql != "" {
+			issueKeys = nil
+			for _, issue := range GetAllIssues(jiraClient, jql) {
+				issueKeys = append(issueKeys, issue.Key)
+			}
+			if len(issueKeys) == 0 {
+				log.Fatalf("No issues matched %q", jql)
+			}
+		}
+
+		failed := 0
+		for _, issueKey := range issueKeys {
+			comment, resp, err := saveComment(jiraClient, issueKey, "", body, visibility)
+			if err != nil {
+				fmt.Printf("Failed %s: %v\n", issueKey, jira.NewJiraError(resp, err))
+				failed++
+				continue
+			}
+			fmt.Printf("Commented on %s (%s)\n", issueKey, comment.ID)
+		}
+
+		if failed > 0 {
+			fmt.Printf("%d of %d issues failed\n", failed, len(issueKeys))
+			os.Exit(1)
+		}
+	},
+}
+
+var issueCommentListCmd = &cobra.Command{
+	Use:   "list KEY",
+	Short: "List comments",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+
+		jiraClient := newJiraClient()
+		issue, resp, err := jiraClient.Issue.Get(args[0], &jira.GetQueryOptions{Fields: "comment"})
+		checkJiraError(resp, err)
+
+		comments := []*jira.Comment{}
+		if issue.Fields.Comments != nil {
+			comments = issue.Fields.Comments.Comments
+		}
+
+		if output == "json" {
+			jsonBytes, err := json.MarshalIndent(comments, "", "  ")
+			if err != nil {
+				panic(err)
+			}
+			fmt.Println(string(jsonBytes))
+			return
+		}
+
+		st := termStyle{color: isTerminal(os.Stdout)}
+		for i, c := range comments {
+			if i > 0 {
+				fmt.Println()
+			}
+			author := c.Author
+			header := fmt.Sprintf("%s %s %s", st.dim("#"+c.ID), st.bold(userDisplayName(&author)), st.dim("· "+formatJiraTimestamp(c.Created)))
+			if c.Visibility.Value != "" {
+				header += st.yellow(fmt.Sprintf(" [%s: %s]", c.Visibility.Type, c.Visibility.Value))
+			}
+			fmt.Println(header)
+			fmt.Println(indent(renderMarkup(c.Body, st), "  "))
+		}
+	},
+}
+
+var issueCommentEditCmd = &cobra.Command{
+	Use:   "edit KEY COMMENT_ID",
+	Short: "Edit a comment",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		issueKey, commentID := args[0], args[1]
+		jiraClient := newJiraClient()
+
+		existing := ""
+		if !cmd.Flags().Changed("body") && !cmd.Flags().Changed("file") {
+			// check the comment exists before opening the editor on it
+			comment, err := getComment(jiraClient, issueKey, commentID)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			existing = comment.Body
+		}
+
+		body := commentBody(cmd, existing)
+		comment, resp, err := saveComment(jiraClient, issueKey, commentID, body, commentVisibility(cmd))
+		checkJiraError(resp, err)
+		fmt.Printf("Updated comment %s on %s\n", comment.ID, issueKey)
+	},
+}
+
+var issueCommentDeleteCmd = &cobra.Command{
+	Use:   "delete KEY COMMENT_ID",
+	Short: "Delete a comment",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		jiraClient := newJiraClient()
+		if err := jiraClient.Issue.DeleteComment(args[0], args[1]); err != nil {
+			panic(err)
+		}
+		fmt.Printf("Deleted comment %s on %s\n", args[1], args[0])
+	},
+}
+
+// getComment returns a comment of an issue, with a not found error when the
+// issue does not have it.
+func getComment(jiraClient *jira.Client, issueKey string, commentID string) (*jira.Comment, error) {
+	req, err := jiraClient.NewRequest("GET", fmt.Sprintf("rest/api/2/issue/%s/comment/%s", url.PathEscape(issueKey), url.PathEscape(commentID)), nil)
+	if err != nil {
+		return nil, err
+	}
+	comment := new(jira.Comment)
+	resp, err := jiraClient.Do(req, comment)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("comment %s not found on %s", commentID, issueKey)
+	}
+	if err != nil {
+		return nil, jira.NewJiraError(resp, err)
+	}
+	return comment, nil
+}
+
+// commentBody reads the comment body from --body, --file or $EDITOR.
+func commentBody(cmd *cobra.Command, initial string) string {
+	body, _ := cmd.Flags().GetString("body")
+	file, _ := cmd.Flags().GetString("file")
+
+	var err error
+	switch {
+	case body != "":
+	case file != "":
+		body, err = readTextInput(file)
+	default:
+		body, err = editText(initial)
+	}
+	if err != nil {
+		log.Fatalf("Unable to read comment body: %v", err)
+	}
+
+	body = strings.TrimSpace(body)
+	if body == "" {
+		log.Fatalf("Empty comment, nothing to do")
+	}
+	return body
+}
+
+// commentVisibility builds the visibility restriction from --role or --group.
+func commentVisibility(cmd *cobra.Command) map[string]string {
+	role, _ := cmd.Flags().GetString("role")
+	group, _ := cmd.Flags().GetString("group")
+
+	switch {
+	case role != "" && group != "":
+		log.Fatalf("Use only one of --role or --group")
+	case role != "":
+		return map[string]string{"type": "role", "value": role}
+	case group != "":
+		return map[string]string{"type": "group", "value": group}
+	}
+	return nil
+}
+
+// saveComment creates a comment, or updates commentID when set. The request is
+// built by hand because jira.Comment always sends an empty visibility object,
+// which Jira rejects.
+func saveComment(jiraClient *jira.Client, issueKey string, commentID string, body string, visibility map[string]string) (*jira.Comment, *jira.Response, error) {
+	payload := map[string]interface{}{"body": body}
+	if visibility != nil {
+		payload["visibility"] = visibility
+	}
+
+	method, path := "POST", fmt.Sprintf("rest/api/2/issue/%s/comment", issueKey)
+	if commentID != "" {
+		method, path = "PUT", fmt.Sprintf("rest/api/2/issue/%s/comment/%s", issueKey, commentID)
+	}
+
+	req, err := jiraClient.NewRequest(method, path, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	comment := new(jira.Comment)
+	resp, err := jiraClient.Do(req, comment)
+	return comment, resp, err
+}
+
+func init() {
+	issuesCmd.AddCommand(issueCommentCmd)
+	issueCommentCmd.AddCommand(issueCommentAddCmd, issueCommentListCmd, issueCommentEditCmd, issueCommentDeleteCmd)
+
+	for _, c := range []*cobra.Command{issueCommentAddCmd, issueCommentEditCmd} {
+		c.Flags().StringP("body", "m", "", "Comment body")
+		c.Flags().StringP("file", "F", "", "Read the comment body from a file, - for stdin")
+		c.Flags().String("role", "", "Restrict visibility to a project role")
+		c.Flags().String("group", "", "Restrict visibility to a group")
+	}
+
+	issueCommentAddCmd.Flags().StringP("jql", "j", "", "Comment on every issue matching this JQL")
+	issueCommentListCmd.Flags().StringP("output", "o", "text", "Output format: text or json")
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"io"
+	"log"
 )
 
 // addIssueCmd represents the addIssue command
@@ -16,7 +17,9 @@ var addIssueCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		summary, _ := cmd.Flags().GetString("summary")
 		description, _ := cmd.Flags().GetString("description")
-		labels, _ := cmd.Flags().GetStringArray("label")
+		labels, _ := cmd.Flags().GetStringSlice("label")
+		typeName, _ := cmd.Flags().GetString("type")
+		parentKey, _ := cmd.Flags().GetString("parent")
 		projectKey := viper.GetString("project")
 
 		//http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
@@ -35,18 +38,21 @@ var addIssueCmd = &cobra.Command{
 			panic(err)
 		}
 
-		jiraProject, resp, err := jiraClient.Project.Get(projectKey)
+		jiraProject := getProject(jiraClient, projectKey)
+
+		issueType, err := resolveIssueType(jiraProject, typeName)
 		if err != nil {
-			body, _ := io.ReadAll(resp.Body)
-			fmt.Println(string(body))
-			panic(err)
+			log.Fatalf("%v", err)
+		}
+		if issueType.Subtask && parentKey == "" {
+			log.Fatalf("%s issues need a --parent", issueType.Name)
 		}
 
 		i := jira.Issue{
 			Fields: &jira.IssueFields{
 				Description: description,
 				Type: jira.IssueType{
-					Name: "Task",
+					ID: issueType.ID,
 				},
 				Project: jira.Project{
 					Key: jiraProject.Key,
@@ -55,6 +61,9 @@ var addIssueCmd = &cobra.Command{
 				Labels:  labels,
 			},
 		}
+		if parentKey != "" {
+			i.Fields.Parent = &jira.Parent{Key: parentKey}
+		}
 		newIssue, resp, err := jiraClient.Issue.Create(&i)
 		if err != nil {
 			body, _ := io.ReadAll(resp.Body)
@@ -70,6 +79,8 @@ func init() {
 	issuesCmd.AddCommand(addIssueCmd)
 	addIssueCmd.Flags().StringP("summary", "s", "", "Summary of the issue")
 	addIssueCmd.Flags().StringP("type", "t", "task", "Type of issue. EG: task, sub-task, epic, bug")
+	addIssueCmd.RegisterFlagCompletionFunc("type", completeIssueTypes)
+	addIssueCmd.Flags().String("parent", "", "Parent issue of a sub-task, or epic in team-managed projects")
 	addIssueCmd.Flags().StringSliceP("label", "l", []string{}, "Labels of the issue")
 	addIssueCmd.Flags().StringP("description", "d", "", "Description of the issue")
 }
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// issueEditCmd represents the issues edit command
+var issueEditCmd = &cobra.Command{
+	Use:   "edit KEY",
+	Short: "Edit an issue",
+	Long: `Edit fields on an existing issue.
+
+Changes are sent as Jira update operations, so adding or removing labels
+leaves any other labels on the issue untouched.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		issueKey := args[0]
+		summary, _ := cmd.Flags().GetString("summary")
+		addLabels, _ := cmd.Flags().GetStringSlice("add-label")
+		removeLabels, _ := cmd.Flags().GetStringSlice("remove-label")
+		assignee, _ := cmd.Flags().GetString("assignee")
+		priority, _ := cmd.Flags().GetString("priority")
+		setFields, _ := cmd.Flags().GetStringArray("set")
+		descriptionFile, _ := cmd.Flags().GetString("description-file")
+
+		jiraClient := newJiraClient()
+		update := map[string][]map[string]interface{}{}
+		addOp := func(field string, op string, value interface{}) {
+			update[field] = append(update[field], map[string]interface{}{op: value})
+		}
+
+		if cmd.Flags().Changed("summary") {
+			addOp("summary", "set", summary)
+		}
+		for _, label := range addLabels {
+			addOp("labels", "add", label)
+		}
+		for _, label := range removeLabels {
+			addOp("labels", "remove", label)
+		}
+		if cmd.Flags().Changed("assignee") {
+			if assignee == "" || assignee == "none" {
+				addOp("assignee", "set", nil)
+			} else {
+				projectKey, _, _ := strings.Cut(issueKey, "-")
+				resolver := newUserResolver(jiraClient, projectKey)
+				user, err := resolver.resolve(assignee)
+				if err != nil {
+					log.Fatalf("Unable to resolve assignee: %v", err)
+				}
+				addOp("assignee", "set", resolver.ref(user))
+			}
+		}
+		if priority != "" {
+			addOp("priority", "set", map[string]string{"name": priority})
+		}
+		if descriptionFile != "" {
+			description, err := readTextInput(descriptionFile)
+			if err != nil {
+				log.Fatalf("Unable to read description: %v", err)
+			}
+			addOp("description", "set", description)
+		}
+		for _, kv := range setFields {
+			name, value, found := strings.Cut(kv, "=")
+			if !found {
+				log.Fatalf("Invalid --set %q, expected field=value", kv)
+			}
+			addOp(mustCustomFieldID(name), "set", parseFieldValue(value))
+		}
+
+		if len(update) == 0 {
+			log.Fatalf("Nothing to update for %s", issueKey)
+		}
+
+		resp, err := jiraClient.Issue.UpdateIssue(issueKey, map[string]interface{}{
+			"update": update,
+		})
+		checkJiraError(resp, err)
+
+		fmt.Printf("Updated %s\n", issueKey)
+	},
+}
+
+// mustCustomFieldID resolves a custom field name from the config, exiting
+// when it is not configured.
+func mustCustomFieldID(name string) string {
+	fieldID, ok := Config.CustomFieldID(strings.TrimSpace(name))
+	if !ok {
+		log.Fatalf("Unknown custom field %q, add it to custom_fields in your config", name)
+	}
+	return fieldID
+}
+
+func init() {
+	issuesCmd.AddCommand(issueEditCmd)
+	issueEditCmd.Flags().StringP("summary", "s", "", "New summary")
+	issueEditCmd.Flags().StringSliceP("add-label", "l", []string{}, "Labels to add")
+	issueEditCmd.Flags().StringSliceP("remove-label", "L", []string{}, "Labels to remove")
+	issueEditCmd.Flags().StringP("assignee", "a", "", "Assignee email, username, account id, me or lead, \"none\" to unassign")
+	issueEditCmd.Flags().String("priority", "", "Priority name")
+	issueEditCmd.Flags().StringArray("set", []string{}, "Set a custom field from custom_fields, EG: --set \"Story Points=3\"")
+	issueEditCmd.Flags().StringP("description-file", "F", "", "Read the description from a file, - for stdin")
+}
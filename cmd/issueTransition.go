@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/spf13/cobra"
+)
+
+// issueTransitionCmd represents the issues transition command
+var issueTransitionCmd = &cobra.Command{
+	Use:   "transition [KEY] [STATUS]",
+	Short: "Move issues through the workflow",
+	Long: `Move issues through the workflow.
+
+Without a status the available transitions for the issue are listed. The
+status can be the name of the target status or of the transition itself.
+With --jql the transition is applied to every matching issue, a query that
+matches nothing is an error:
+
+  gojitzu issues transition PROJ-1
+  gojitzu issues transition PROJ-1 Done --resolution Fixed --comment "shipped"
+  gojitzu issues transition --jql "project = PROJ AND status = Review" Done`,
+	Args: cobra.RangeArgs(0, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		jql, _ := cmd.Flags().GetString("jql")
+		resolution, _ := cmd.Flags().GetString("resolution")
+		comment, _ := cmd.Flags().GetString("comment")
+		fieldValues, _ := cmd.Flags().GetStringArray("field")
+
+		var issueKeys []string
+		var status string
+		jiraClient := newJiraClient()
+
+		if jql != "" {
+			if len(args) != 1 {
+				log.Fatalf("Expected exactly one STATUS argument with --jql")
+			}
+			status = args[0]
+			for _, issue := range GetAllIssues(jiraClient, jql) {
+				issueKeys = append(issueKeys, issue.Key)
+			}
+			if len(issueKeys) == 0 {
+				log.Fatalf("No issues matched %q", jql)
+			}
+		} else {
+			if len(args) == 0 {
+				log.Fatalf("Expected an issue KEY or --jql")
+			}
+			issueKeys = []string{args[0]}
+			if len(args) == 2 {
+				status = args[1]
+			}
+		}
+
+		if status == "" {
+			transitions, resp, err := jiraClient.Issue.GetTransitions(issueKeys[0])
+			checkJiraError(resp, err)
+			printTransitions(transitions)
+			return
+		}
+
+		fields := map[string]interface{}{}
+		if resolution != "" {
+			fields["resolution"] = map[string]string{"name": resolution}
+		}
+		for _, kv := range fieldValues {
+			name, value, found := strings.Cut(kv, "=")
+			if !found {
+				log.Fatalf("Invalid --field %q, expected field=value", kv)
+			}
+			fields[mustCustomFieldID(name)] = parseFieldValue(value)
+		}
+
+		failed := 0
+		for _, issueKey := range issueKeys {
+			if err := transitionIssue(jiraClient, issueKey, status, fields, comment); err != nil {
+				fmt.Printf("Failed %s: %v\n", issueKey, err)
+				failed++
+				continue
+			}
+			fmt.Printf("Transitioned %s to %s\n", issueKey, status)
+		}
+
+		if failed > 0 {
+			fmt.Printf("%d of %d issues failed\n", failed, len(issueKeys))
+			os.Exit(1)
+		}
+	},
+}
+
+// transitionIssue moves an issue to status, setting any transition screen
+// fields and adding a comment if one is given.
+func transitionIssue(jiraClient *jira.Client, issueKey string, status string, fields map[string]interface{}, comment string) error {
+	transitions, _, err := jiraClient.Issue.GetTransitions(issueKey)
+	if err != nil {
+		return err
+	}
+
+	transition, err := findTransition(transitions, status)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for name, field := range transition.Fields {
+		if _, set := fields[name]; field.Required && !set {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("transition %q requires fields: %s", transition.Name, strings.Join(missing, ", "))
+	}
+
+	// Jira rejects fields that are not on the transition screen
+	var offScreen []string
+	for name := range fields {
+		if _, onScreen := transition.Fields[name]; !onScreen {
+			offScreen = append(offScreen, name)
+		}
+	}
+	if len(offScreen) > 0 {
+		sort.Strings(offScreen)
+		return fmt.Errorf("transition %q does not have fields: %s", transition.Name, strings.Join(offScreen, ", "))
+	}
+
+	payload := map[string]interface{}{
+		"transition": map[string]string{"id": transition.ID},
+	}
+	if len(fields) > 0 {
+		payload["fields"] = fields
+	}
+	if comment != "" {
+		payload["update"] = map[string]interface{}{
+			"comment": []map[string]interface{}{
+				{"add": map[string]string{"body": comment}},
+			},
+		}
+	}
+
+	_, err = jiraClient.Issue.DoTransitionWithPayload(issueKey, payload)
+	return err
+}
+
+// findTransition resolves a target status or transition name to a transition.
+func findTransition(transitions []jira.Transition, status string) (*jira.Transition, error) {
+	for i, t := range transitions {
+		if strings.EqualFold(t.To.Name, status) {
+			return &transitions[i], nil
+		}
+	}
+	for i, t := range transitions {
+		if strings.EqualFold(t.Name, status) || t.ID == status {
+			return &transitions[i], nil
+		}
+	}
+
+	var available []string
+	for _, t := range transitions {
+		available = append(available, t.To.Name)
+	}
+	return nil, fmt.Errorf("no transition to %q, available: %s", status, strings.Join(available, ", "))
+}
+
+func printTransitions(transitions []jira.Transition) {
+	for _, t := range transitions {
+		var required []string
+		for name, field := range t.Fields {
+			if field.Required {
+				required = append(required, name)
+			}
+		}
+		sort.Strings(required)
+
+		line := fmt.Sprintf("%-6s %s -> %s", t.ID, t.Name, t.To.Name)
+		if len(required) > 0 {
+			line += fmt.Sprintf(" (requires: %s)", strings.Join(required, ", "))
+		}
+		fmt.Println(line)
+	}
+}
+
+func init() {
+	issuesCmd.AddCommand(issueTransitionCmd)
+	issueTransitionCmd.Flags().StringP("jql", "j", "", "Transition every issue matching this JQL")
+	issueTransitionCmd.Flags().StringP("resolution", "r", "", "Resolution to set, EG: Done, Fixed")
+	issueTransitionCmd.Flags().StringP("comment", "c", "", "Comment to add with the transition")
+	issueTransitionCmd.Flags().StringArray("field", []string{}, "Set a transition screen field from custom_fields, EG: --field \"Story Points=3\"")
+}
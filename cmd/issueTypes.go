@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+// resolveIssueType finds the issue type of a project for a name, after the
+// issue_types mapping of the config. Names match issue type names or ids.
+// sub-task falls back to the project's sub-task type when no type has that
+// name, as instances name it differently (Subtask, Sous-tâche). Issues should
+// be created with the id of the type, so they work in any language.
+func resolveIssueType(project *jira.Project, name string) (jira.IssueType, error) {
+	mapped := Config.IssueType(project.Key, name)
+
+	for _, issueType := range project.IssueTypes {
+		if issueType.ID == mapped || strings.EqualFold(issueType.Name, mapped) {
+			return issueType, nil
+		}
+	}
+
+	if strings.EqualFold(mapped, "sub-task") {
+		for _, issueType := range project.IssueTypes {
+			if issueType.Subtask {
+				return issueType, nil
+			}
+		}
+	}
+
+	var names []string
+	for _, issueType := range project.IssueTypes {
+		names = append(names, issueType.Name)
+	}
+	return jira.IssueType{}, fmt.Errorf("unknown issue type %q in %s, expected one of %s or an issue_types mapping in your config", mapped, project.Key, strings.Join(names, ", "))
+}
+
+// checkTemplateTypes makes sure the issue types of the tasks, and of the epic
+// when one is created, exist and that sub-tasks use a sub-task type.
+func checkTemplateTypes(project *jira.Project, tasks []Task, createEpic bool) error {
+	check := func(title string, name string, subtask bool) error {
+		issueType, err := resolveIssueType(project, name)
+		if err != nil {
+			return fmt.Errorf("%s: %v", title, err)
+		}
+		if issueType.Subtask != subtask {
+			if subtask {
+				return fmt.Errorf("%s: %s is not a sub-task type", title, issueType.Name)
+			}
+			return fmt.Errorf("%s: %s is a sub-task type", title, issueType.Name)
+		}
+		return nil
+	}
+
+	if createEpic {
+		if err := check("epic", "epic", false); err != nil {
+			return err
+		}
+	}
+	for _, task := range tasks {
+		if err := check(task.Title, taskType(task.Type, "task"), false); err != nil {
+			return err
+		}
+		for _, subTask := range task.SubTasks {
+			if err := check(subTask.Title, taskType(subTask.Type, "sub-task"), true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func taskType(name string, def string) string {
+	if name == "" {
+		return def
+	}
+	return name
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/spf13/cobra"
+)
+
+// issueViewCmd represents the issues view command
+var issueViewCmd = &cobra.Command{
+	Use:   "view KEY",
+	Short: "View an issue",
+	Long:  `View a single issue with its sub-tasks, links, attachments and comments.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		issueKey := args[0]
+		web, _ := cmd.Flags().GetBool("web")
+		output, _ := cmd.Flags().GetString("output")
+
+		if web {
+			fmt.Println(browseURL(issueKey))
+			return
+		}
+
+		jiraClient := newJiraClient()
+		issue, resp, err := jiraClient.Issue.Get(issueKey, nil)
+		checkJiraError(resp, err)
+
+		switch output {
+		case "json":
+			jsonBytes, err := json.MarshalIndent(issue, "", "  ")
+			if err != nil {
+				panic(err)
+			}
+			fmt.Println(string(jsonBytes))
+		case "text":
+			fmt.Println(renderIssue(issue, termStyle{color: isTerminal(os.Stdout)}))
+		default:
+			fmt.Printf("Unknown output format %q, expected text or json\n", output)
+			os.Exit(1)
+		}
+	},
+}
+
+// renderIssue formats an issue for reading in a terminal.
+func renderIssue(issue *jira.Issue, st termStyle) string {
+	var b strings.Builder
+	f := issue.Fields
+
+	fmt.Fprintf(&b, "%s  %s\n", st.bold(st.blue(issue.Key)), st.bold(f.Summary))
+
+	meta := []string{f.Type.Name}
+	if f.Status != nil {
+		meta = append(meta, st.green(f.Status.Name))
+	}
+	if f.Priority != nil {
+		meta = append(meta, st.yellow(f.Priority.Name))
+	}
+	if f.Resolution != nil {
+		meta = append(meta, f.Resolution.Name)
+	}
+	fmt.Fprintf(&b, "%s\n", strings.Join(meta, " · "))
+	fmt.Fprintf(&b, "%s\n\n", st.dim(browseURL(issue.Key)))
+
+	fmt.Fprintf(&b, "%s %s    %s %s\n", st.dim("Assignee:"), userDisplayName(f.Assignee), st.dim("Reporter:"), userDisplayName(f.Reporter))
+	if len(f.Labels) > 0 {
+		fmt.Fprintf(&b, "%s %s\n", st.dim("Labels:"), strings.Join(f.Labels, ", "))
+	}
+	if len(f.Components) > 0 {
+		var names []string
+		for _, c := range f.Components {
+			names = append(names, c.Name)
+		}
+		fmt.Fprintf(&b, "%s %s\n", st.dim("Components:"), strings.Join(names, ", "))
+	}
+	if f.Parent != nil {
+		fmt.Fprintf(&b, "%s %s\n", st.dim("Parent:"), f.Parent.Key)
+	}
+	if f.Epic != nil {
+		fmt.Fprintf(&b, "%s %s %s\n", st.dim("Epic:"), f.Epic.Key, f.Epic.Summary)
+	}
+
+	dates := []string{
+		fmt.Sprintf("%s %s", st.dim("Created:"), formatJiraTime(time.Time(f.Created))),
+		fmt.Sprintf("%s %s", st.dim("Updated:"), formatJiraTime(time.Time(f.Updated))),
+	}
+	if due := time.Time(f.Duedate); !due.IsZero() {
+		dates = append(dates, fmt.Sprintf("%s %s", st.dim("Due:"), due.Format("2006-01-02")))
+	}
+	fmt.Fprintf(&b, "%s\n", strings.Join(dates, "    "))
+
+	b.WriteString("\n" + st.bold("Description") + "\n")
+	if strings.TrimSpace(f.Description) == "" {
+		b.WriteString(st.dim("  No description") + "\n")
+	} else {
+		b.WriteString(indent(renderMarkup(f.Description, st), "  ") + "\n")
+	}
+
+	if len(f.Subtasks) > 0 {
+		fmt.Fprintf(&b, "\n%s\n", st.bold(fmt.Sprintf("Sub-tasks (%d)", len(f.Subtasks))))
+		for _, sub := range f.Subtasks {
+			status := ""
+			if sub.Fields.Status != nil {
+				status = sub.Fields.Status.Name
+			}
+			fmt.Fprintf(&b, "  %s  %s  %s\n", st.blue(sub.Key), st.green("["+status+"]"), sub.Fields.Summary)
+		}
+	}
+
+	if len(f.IssueLinks) > 0 {
+		fmt.Fprintf(&b, "\n%s\n", st.bold(fmt.Sprintf("Links (%d)", len(f.IssueLinks))))
+		for _, link := range f.IssueLinks {
+			relation, other := link.Type.Outward, link.OutwardIssue
+			if other == nil {
+				relation, other = link.Type.Inward, link.InwardIssue
+			}
+			if other == nil {
+				continue
+			}
+			summary, status := "", ""
+			if other.Fields != nil {
+				summary = other.Fields.Summary
+				if other.Fields.Status != nil {
+					status = other.Fields.Status.Name
+				}
+			}
+			fmt.Fprintf(&b, "  %s %s  %s  %s\n", st.dim(relation), st.blue(other.Key), st.green("["+status+"]"), summary)
+		}
+	}
+
+	if len(f.Attachments) > 0 {
+		fmt.Fprintf(&b, "\n%s\n", st.bold(fmt.Sprintf("Attachments (%d)", len(f.Attachments))))
+		for _, a := range f.Attachments {
+			fmt.Fprintf(&b, "  %s  %s\n", a.Filename, st.dim(fmt.Sprintf("%s, %s, %s", formatSize(a.Size), a.MimeType, userDisplayName(a.Author))))
+		}
+	}
+
+	if f.Comments != nil && len(f.Comments.Comments) > 0 {
+		fmt.Fprintf(&b, "\n%s\n", st.bold(fmt.Sprintf("Comments (%d)", len(f.Comments.Comments))))
+		for _, c := range f.Comments.Comments {
+			author := c.Author
+			fmt.Fprintf(&b, "\n  %s %s\n", st.bold(userDisplayName(&author)), st.dim("· "+formatJiraTimestamp(c.Created)))
+			b.WriteString(indent(renderMarkup(c.Body, st), "  ") + "\n")
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func userDisplayName(u *jira.User) string {
+	if u == nil {
+		return "Unassigned"
+	}
+	for _, name := range []string{u.DisplayName, u.Name, u.EmailAddress, u.AccountID} {
+		if name != "" {
+			return name
+		}
+	}
+	return "Unknown"
+}
+
+func formatJiraTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// formatJiraTimestamp formats the string timestamps Jira returns for comments.
+func formatJiraTimestamp(ts string) string {
+	t, err := time.Parse("2006-01-02T15:04:05.000-0700", ts)
+	if err != nil {
+		return ts
+	}
+	return formatJiraTime(t)
+}
+
+func formatSize(size int) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := unit, 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGT"[exp])
+}
+
+func indent(text string, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func init() {
+	issuesCmd.AddCommand(issueViewCmd)
+	issueViewCmd.Flags().StringP("output", "o", "text", "Output format: text or json")
+	issueViewCmd.Flags().BoolP("web", "w", false, "Print the browse url instead of fetching the issue")
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/spf13/cobra"
+)
+
+// issueWorklogCmd represents the issues worklog command
+var issueWorklogCmd = &cobra.Command{
+	Use:   "worklog",
+	Short: "Log and list work on issues",
+}
+
+var issueWorklogAddCmd = &cobra.Command{
+	Use:   "add KEY DURATION",
+	Short: "Log work on an issue",
+	Long: `Log work on an issue.
+
+DURATION uses Jira's time tracking format, EG: 2h30m, 1d, 45m, "1w 2d".
+
+  gojitzu issues worklog add PROJ-1 2h30m --comment "triage" --started "2021-06-01 13:00"`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		comment, _ := cmd.Flags().GetString("comment")
+		started, _ := cmd.Flags().GetString("started")
+
+		timeSpent, err := parseWorklogDuration(args[1])
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		startedAt := time.Now()
+		if started != "" {
+			startedAt, err = parseWorklogStarted(started)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+		}
+		startedTime := jira.Time(startedAt)
+
+		jiraClient := newJiraClient()
+		record, resp, err := jiraClient.Issue.AddWorklogRecord(args[0], &jira.WorklogRecord{
+			Comment:   comment,
+			Started:   &startedTime,
+			TimeSpent: timeSpent,
+		})
+		checkJiraError(resp, err)
+
+		fmt.Printf("Logged %s on %s (%s)\n", timeSpent, args[0], record.ID)
+	},
+}
+
+var issueWorklogListCmd = &cobra.Command{
+	Use:   "list KEY",
+	Short: "List work logged on an issue",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+
+		jiraClient := newJiraClient()
+		worklog, resp, err := jiraClient.Issue.GetWorklogs(args[0])
+		checkJiraError(resp, err)
+
+		if output == "json" {
+			jsonBytes, err := json.MarshalIndent(worklog.Worklogs, "", "  ")
+			if err != nil {
+				panic(err)
+			}
+			fmt.Println(string(jsonBytes))
+			return
+		}
+
+		total := 0
+		for _, w := range worklog.Worklogs {
+			started := "-"
+			if w.Started != nil {
+				started = formatJiraTime(time.Time(*w.Started))
+			}
+			fmt.Printf("%-8s %-16s %-20s %8s  %s\n", w.ID, started, userDisplayName(w.Author), w.TimeSpent, strings.ReplaceAll(w.Comment, "\n", " "))
+			total += w.TimeSpentSeconds
+		}
+		fmt.Printf("Total %.2fh\n", float64(total)/3600)
+	},
+}
+
+var worklogDurationRe = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*([wdhm])`)
+
+// parseWorklogDuration validates a duration such as "2h30m" and returns it in
+// the "2h 30m" form Jira expects for timeSpent.
+func parseWorklogDuration(duration string) (string, error) {
+	compact := strings.ReplaceAll(strings.ToLower(duration), " ", "")
+	matches := worklogDurationRe.FindAllStringSubmatch(compact, -1)
+
+	var parts []string
+	consumed := 0
+	for _, m := range matches {
+		parts = append(parts, m[1]+m[2])
+		consumed += len(m[0])
+	}
+
+	if len(parts) == 0 || consumed != len(compact) {
+		return "", fmt.Errorf("invalid duration %q, expected something like 2h30m, 1d or 45m", duration)
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// parseWorklogStarted parses the start of a worklog in local time. A date
+// without a time starts at 09:00 so it does not drift across days in other
+// time zones.
+func parseWorklogStarted(started string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, started); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, started, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", started, time.Local); err == nil {
+		return t.Add(9 * time.Hour), nil
+	}
+	if t, err := time.ParseInLocation("15:04", started, time.Local); err == nil {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.Local), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid start %q, expected YYYY-MM-DD, \"YYYY-MM-DD HH:MM\", HH:MM or RFC3339", started)
+}
+
+func init() {
+	issuesCmd.AddCommand(issueWorklogCmd)
+	issueWorklogCmd.AddCommand(issueWorklogAddCmd, issueWorklogListCmd)
+
+	issueWorklogAddCmd.Flags().StringP("comment", "c", "", "Worklog comment")
+	issueWorklogAddCmd.Flags().StringP("started", "s", "", "When the work started, defaults to now")
+	issueWorklogListCmd.Flags().StringP("output", "o", "text", "Output format: text or json")
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// termStyle applies ANSI styles when color output is enabled.
+type termStyle struct {
+	color bool
+}
+
+func (s termStyle) wrap(code string, text string) string {
+	if !s.color || text == "" {
+		return text
+	}
+	return "\x1b[" + code + "m" + text + "\x1b[0m"
+}
+
+func (s termStyle) bold(text string) string      { return s.wrap("1", text) }
+func (s termStyle) dim(text string) string       { return s.wrap("2", text) }
+func (s termStyle) italic(text string) string    { return s.wrap("3", text) }
+func (s termStyle) underline(text string) string { return s.wrap("4", text) }
+func (s termStyle) cyan(text string) string      { return s.wrap("36", text) }
+func (s termStyle) green(text string) string     { return s.wrap("32", text) }
+func (s termStyle) yellow(text string) string    { return s.wrap("33", text) }
+func (s termStyle) blue(text string) string      { return s.wrap("34", text) }
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+var (
+	wikiHeadingRe   = regexp.MustCompile(`^h([1-6])\.\s+(.*)$`)
+	mdHeadingRe     = regexp.MustCompile(`^(#{2,6})\s+(.*)$`)
+	bulletRe        = regexp.MustCompile(`^([*-]+)\s+(.*)$`)
+	numberedRe      = regexp.MustCompile(`^(#+)\s+(.*)$`)
+	ruleRe          = regexp.MustCompile(`^-{4,}$`)
+	quoteRe         = regexp.MustCompile(`^(?:bq\.|>)\s?(.*)$`)
+	wikiLinkRe      = regexp.MustCompile(`\[([^\]|]+)\|([^\]]+)\]`)
+	wikiBareLinkRe  = regexp.MustCompile(`\[((?:https?|mailto):[^\]]+)\]`)
+	mdLinkRe        = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+	mentionRe       = regexp.MustCompile(`\[~(?:accountid:)?([^\]]+)\]`)
+	wikiMonoRe      = regexp.MustCompile(`\{\{(.+?)\}\}`)
+	mdMonoRe        = regexp.MustCompile("`([^`]+)`")
+	mdBoldRe        = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	wikiBoldRe      = regexp.MustCompile(`(^|[\s(])\*([^*\s][^*]*?)\*($|[\s).,:;!?])`)
+	italicRe        = regexp.MustCompile(`(^|[\s(])_([^_\s][^_]*?)_($|[\s).,:;!?])`)
+	colorTagRe      = regexp.MustCompile(`\{color(?::[^}]*)?\}`)
+	codeBlockTagRe  = regexp.MustCompile("^(?:\\{code(?::[^}]*)?\\}|\\{noformat\\}|```\\S*)")
+	quoteBlockTagRe = regexp.MustCompile(`^\{quote\}`)
+)
+
+// renderMarkup converts Jira wiki markup (and the common Markdown subset people
+// paste into Jira) into plain text suitable for a terminal.
+func renderMarkup(text string, st termStyle) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	var out []string
+	inCode := false
+	inQuote := false
+	numbers := map[int]int{}
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if tag := codeBlockTagRe.FindString(trimmed); tag != "" {
+			inCode = !inCode
+			rest := strings.TrimSpace(strings.TrimPrefix(trimmed, tag))
+			if rest != "" {
+				out = append(out, "    "+st.dim(rest))
+			}
+			continue
+		}
+
+		if inCode {
+			out = append(out, "    "+st.dim(line))
+			continue
+		}
+
+		if quoteBlockTagRe.MatchString(trimmed) {
+			inQuote = !inQuote
+			continue
+		}
+
+		// "## " is a Markdown heading, unless it continues a "# " wiki
+		// numbered list, where it is a nested item
+		mdHeading := mdHeadingRe.MatchString(trimmed) && len(numbers) == 0
+		if !numberedRe.MatchString(trimmed) || mdHeading {
+			numbers = map[int]int{}
+		}
+
+		var rendered string
+		switch {
+		case wikiHeadingRe.MatchString(trimmed):
+			m := wikiHeadingRe.FindStringSubmatch(trimmed)
+			rendered = renderHeading(m[1][0]-'0', renderInline(m[2], st), st)
+		case mdHeading:
+			m := mdHeadingRe.FindStringSubmatch(trimmed)
+			rendered = renderHeading(byte(len(m[1])), renderInline(m[2], st), st)
+		case ruleRe.MatchString(trimmed):
+			rendered = st.dim(strings.Repeat("─", 40))
+		case bulletRe.MatchString(trimmed):
+			m := bulletRe.FindStringSubmatch(trimmed)
+			rendered = strings.Repeat("  ", len(m[1])) + "• " + renderInline(m[2], st)
+		case numberedRe.MatchString(trimmed):
+			m := numberedRe.FindStringSubmatch(trimmed)
+			depth := len(m[1])
+			numbers[depth]++
+			for d := range numbers {
+				if d > depth {
+					delete(numbers, d)
+				}
+			}
+			rendered = fmt.Sprintf("%s%d. %s", strings.Repeat("  ", depth), numbers[depth], renderInline(m[2], st))
+		case quoteRe.MatchString(trimmed):
+			m := quoteRe.FindStringSubmatch(trimmed)
+			rendered = st.dim("│ ") + st.italic(renderInline(m[1], st))
+		case strings.HasPrefix(trimmed, "||"):
+			cells := strings.Split(strings.Trim(trimmed, "|"), "||")
+			for i, cell := range cells {
+				cells[i] = st.bold(renderInline(strings.TrimSpace(cell), st))
+			}
+			rendered = strings.Join(cells, " │ ")
+		case strings.HasPrefix(trimmed, "|"):
+			cells := strings.Split(strings.Trim(trimmed, "|"), "|")
+			for i, cell := range cells {
+				cells[i] = renderInline(strings.TrimSpace(cell), st)
+			}
+			rendered = strings.Join(cells, " │ ")
+		default:
+			rendered = renderInline(line, st)
+		}
+
+		if inQuote {
+			rendered = st.dim("│ ") + rendered
+		}
+		out = append(out, rendered)
+	}
+
+	return strings.TrimRight(strings.Join(out, "\n"), "\n")
+}
+
+func renderHeading(level byte, text string, st termStyle) string {
+	if level <= 2 {
+		return st.bold(st.underline(text))
+	}
+	return st.bold(text)
+}
+
+func renderInline(text string, st termStyle) string {
+	text = colorTagRe.ReplaceAllString(text, "")
+	text = mentionRe.ReplaceAllString(text, "@$1")
+	text = wikiLinkRe.ReplaceAllStringFunc(text, func(s string) string {
+		m := wikiLinkRe.FindStringSubmatch(s)
+		return st.underline(m[1]) + " " + st.dim("("+m[2]+")")
+	})
+	text = mdLinkRe.ReplaceAllStringFunc(text, func(s string) string {
+		m := mdLinkRe.FindStringSubmatch(s)
+		return st.underline(m[1]) + " " + st.dim("("+m[2]+")")
+	})
+	text = wikiBareLinkRe.ReplaceAllStringFunc(text, func(s string) string {
+		return st.underline(wikiBareLinkRe.FindStringSubmatch(s)[1])
+	})
+	text = wikiMonoRe.ReplaceAllStringFunc(text, func(s string) string {
+		return st.cyan(wikiMonoRe.FindStringSubmatch(s)[1])
+	})
+	text = mdMonoRe.ReplaceAllStringFunc(text, func(s string) string {
+		return st.cyan(mdMonoRe.FindStringSubmatch(s)[1])
+	})
+	text = mdBoldRe.ReplaceAllStringFunc(text, func(s string) string {
+		return st.bold(mdBoldRe.FindStringSubmatch(s)[1])
+	})
+	text = wikiBoldRe.ReplaceAllStringFunc(text, func(s string) string {
+		m := wikiBoldRe.FindStringSubmatch(s)
+		return m[1] + st.bold(m[2]) + m[3]
+	})
+	text = italicRe.ReplaceAllStringFunc(text, func(s string) string {
+		m := italicRe.FindStringSubmatch(s)
+		return m[1] + st.italic(m[2]) + m[3]
+	})
+	return text
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkupHeadings(t *testing.T) {
+	out := renderMarkup("h1. Title\n## Section\n# first\n# second", termStyle{})
+	lines := strings.Split(out, "\n")
+	if len(lines) < 4 {
+		t.Fatalf("got %q", out)
+	}
+	if strings.Contains(lines[1], "1.") || !strings.Contains(lines[1], "Section") {
+		t.Errorf("## heading rendered as %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "1. first") || !strings.Contains(lines[3], "2. second") {
+		t.Errorf("numbered list rendered as %q", lines[2:])
+	}
+}
+
+func TestRenderMarkupNestedNumberedList(t *testing.T) {
+	out := renderMarkup("# first\n## nested\n## other\n# second\n\n## Section", termStyle{})
+	want := []string{"  1. first", "    1. nested", "    2. other", "  2. second", ""}
+	lines := strings.Split(out, "\n")
+	if len(lines) != 6 {
+		t.Fatalf("got %q", out)
+	}
+	for i, line := range want {
+		if lines[i] != line {
+			t.Errorf("line %d is %q, want %q", i, lines[i], line)
+		}
+	}
+	if strings.Contains(lines[5], "1.") || !strings.Contains(lines[5], "Section") {
+		t.Errorf("## heading after the list rendered as %q", lines[5])
+	}
+}
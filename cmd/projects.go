@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/viper"
 	"io"
 	"net/http"
+	"strings"
 )
 
 // projectsCmd represents the projects command
@@ -63,3 +64,23 @@ func init() {
 	// is called directly, e.g.:
 	// projectsCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
+
+// isTeamManaged reports whether a project is team-managed (next-gen). The
+// project style is not part of go-jira's Project.
+func isTeamManaged(jiraClient *jira.Client, projectKey string) bool {
+	project := struct {
+		Style      string `json:"style"`
+		Simplified bool   `json:"simplified"`
+	}{}
+	cached("project-style", projectKey, &project, func() error {
+		req, err := jiraClient.NewRequest("GET", "rest/api/2/project/"+projectKey, nil)
+		if err != nil {
+			panic(err)
+		}
+		resp, err := jiraClient.Do(req, &project)
+		checkJiraError(resp, err)
+		return nil
+	})
+
+	return project.Simplified || strings.EqualFold(project.Style, "next-gen")
+}
@@ -2,11 +2,8 @@ package cmd
 
 import (
 	"fmt"
-	"io/ioutil"
-	"log"
 	"os"
 	"path"
-	"path/filepath"
 
 	"github.com/defektive/gojitzu/pkg/config"
 	homedir "github.com/mitchellh/go-homedir"
@@ -18,68 +15,6 @@ import (
 var cfgFile string
 var labelsFlag []string
 
-type Task struct {
-	Title       string    `yaml:"title"`
-	Description string    `yaml:"description"`
-	Labels      []string  `yaml:"labels"`
-	Prefixable  bool      `yaml:"prefixable"`
-	SubTasks    []SubTask `yaml:"subtasks"`
-}
-
-type SubTask struct {
-	Title       string   `yaml:"title"`
-	Description string   `yaml:"description"`
-	Labels      []string `yaml:"labels"`
-	Prefixable  bool     `yaml:"prefixable"`
-}
-
-type Template struct {
-	Version  string   `yaml:"version"`
-	Includes []string `yaml:"includes,omitempty"`
-	Tasks    []Task   `yaml:"tasks"`
-}
-
-func (tpl *Template) load(baseDir string, templatePath string, includedSoFar ...map[string]bool) *Template {
-	fullPath := filepath.Join(baseDir, templatePath)
-	yamlFile, err := ioutil.ReadFile(fullPath)
-	if err != nil {
-		log.Printf("yamlFile.Get err #%v ", err)
-	}
-
-	err = yaml.Unmarshal(yamlFile, tpl)
-	if err != nil {
-		log.Fatalf("Unmarshal: %v", err)
-	}
-
-	included := make(map[string]bool)
-	for _, inc := range includedSoFar {
-		for key := range inc {
-			if _, found := included[key]; found {
-				continue
-			}
-			included[key] = true
-		}
-	}
-
-	if includedSoFar == nil {
-		included[fullPath] = true
-	}
-
-	for _, includePath := range tpl.Includes {
-		fullIncludePath := filepath.Join(baseDir, includePath)
-		if _, found := included[fullIncludePath]; found {
-			continue
-		}
-		included[fullIncludePath] = true
-
-		var includedTpl Template
-		includedTpl.load(baseDir, includePath, included)
-		tpl.Tasks = append(tpl.Tasks, includedTpl.Tasks...)
-	}
-
-	return tpl
-}
-
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
 	Use:   "gojitzu",
@@ -111,15 +46,18 @@ func init() {
 	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.gojitzu.yaml)")
 	RootCmd.PersistentFlags().StringP("baseurl", "b", "", "base url for jira")
 	RootCmd.PersistentFlags().StringP("project", "p", "", "project key")
-	RootCmd.PersistentFlags().StringP("templatepath", "T", path.Join(home, ".gojitzu-templates"), "$HOME/.gojitzu-templates")
+	RootCmd.RegisterFlagCompletionFunc("project", completeProjects)
+	RootCmd.PersistentFlags().StringSliceP("templatepath", "I", []string{path.Join(home, ".gojitzu-templates")}, "template directories, searched in order")
 	RootCmd.PersistentFlags().StringP("username", "U", "", "username to use")
 	RootCmd.PersistentFlags().StringP("password", "P", "", "password/token")
+	RootCmd.PersistentFlags().Bool("no-cache", false, "do not use the cached Jira metadata")
 
 	viper.BindPFlag("baseurl", RootCmd.PersistentFlags().Lookup("baseurl"))
 	viper.BindPFlag("project", RootCmd.PersistentFlags().Lookup("project"))
 	viper.BindPFlag("username", RootCmd.PersistentFlags().Lookup("username"))
 	viper.BindPFlag("password", RootCmd.PersistentFlags().Lookup("password"))
 	viper.BindPFlag("templatepath", RootCmd.PersistentFlags().Lookup("templatepath"))
+	viper.BindPFlag("no_cache", RootCmd.PersistentFlags().Lookup("no-cache"))
 }
 
 var Config = config.ConfigMap{}
@@ -0,0 +1,489 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+type Task struct {
+	ID    string `yaml:"id,omitempty"`
+	Title string `yaml:"title"`
+	// Type is the issue type, Task when empty
+	Type         string                 `yaml:"type,omitempty"`
+	Description  string                 `yaml:"description,omitempty"`
+	Labels       []string               `yaml:"labels,omitempty"`
+	Tags         []string               `yaml:"tags,omitempty"`
+	Prefixable   bool                   `yaml:"prefixable,omitempty"`
+	When         string                 `yaml:"when,omitempty"`
+	Foreach      *Foreach               `yaml:"foreach,omitempty"`
+	Assignee     string                 `yaml:"assignee,omitempty"`
+	Reporter     string                 `yaml:"reporter,omitempty"`
+	Watchers     []string               `yaml:"watchers,omitempty"`
+	Due          string                 `yaml:"due,omitempty"`
+	Start        string                 `yaml:"start,omitempty"`
+	Attachments  []string               `yaml:"attachments,omitempty"`
+	CustomFields map[string]interface{} `yaml:"custom_fields,omitempty"`
+	Links        []TaskLink             `yaml:"links,omitempty"`
+	// InsertAfter places a task of a template using extends after the task
+	// with this id instead of at the end
+	InsertAfter string    `yaml:"insert_after,omitempty"`
+	SubTasks    []SubTask `yaml:"subtasks,omitempty"`
+
+	// templateDir is the directory of the template file the task came from
+	templateDir string
+	// includedFrom are the templates the task was included through, outermost
+	// first
+	includedFrom []string
+}
+
+type SubTask struct {
+	ID    string `yaml:"id,omitempty"`
+	Title string `yaml:"title"`
+	// Type is the issue type, Sub-task when empty
+	Type         string                 `yaml:"type,omitempty"`
+	Description  string                 `yaml:"description,omitempty"`
+	Labels       []string               `yaml:"labels,omitempty"`
+	Tags         []string               `yaml:"tags,omitempty"`
+	Prefixable   bool                   `yaml:"prefixable,omitempty"`
+	When         string                 `yaml:"when,omitempty"`
+	Assignee     string                 `yaml:"assignee,omitempty"`
+	Reporter     string                 `yaml:"reporter,omitempty"`
+	Watchers     []string               `yaml:"watchers,omitempty"`
+	Due          string                 `yaml:"due,omitempty"`
+	Start        string                 `yaml:"start,omitempty"`
+	Attachments  []string               `yaml:"attachments,omitempty"`
+	CustomFields map[string]interface{} `yaml:"custom_fields,omitempty"`
+	Links        []TaskLink             `yaml:"links,omitempty"`
+
+	templateDir string
+}
+
+type Template struct {
+	Version     string   `yaml:"version"`
+	Name        string   `yaml:"name,omitempty"`
+	Description string   `yaml:"description,omitempty"`
+	Owner       string   `yaml:"owner,omitempty"`
+	Tags        []string `yaml:"tags,omitempty"`
+
+	// Epic describes the epic to create when no --epic is given
+	Epic *EpicSpec `yaml:"epic,omitempty"`
+
+	// Extends is a base template whose tasks this one specializes with
+	// Override, Remove and the insert_after of its own tasks
+	Extends  string                   `yaml:"extends,omitempty"`
+	Override map[string]yaml.MapSlice `yaml:"override,omitempty"`
+	Remove   []string                 `yaml:"remove,omitempty"`
+
+	Includes []string               `yaml:"includes,omitempty"`
+	Vars     map[string]interface{} `yaml:"vars,omitempty"`
+	Tasks    []Task                 `yaml:"tasks"`
+}
+
+// extend replaces the tasks of the template with the tasks of its base, after
+// applying remove and override, and inserts its own tasks into them.
+func (tpl *Template) extend(base Template) error {
+	tasks := base.Tasks
+
+	for _, id := range tpl.Remove {
+		found := false
+		for i := 0; i < len(tasks) && !found; i++ {
+			if tasks[i].ID == id {
+				tasks = append(tasks[:i:i], tasks[i+1:]...)
+				found = true
+				break
+			}
+			subTasks := tasks[i].SubTasks
+			for j := range subTasks {
+				if subTasks[j].ID == id {
+					tasks[i].SubTasks = append(subTasks[:j:j], subTasks[j+1:]...)
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			return fmt.Errorf("remove: no task with id %q in %s", id, tpl.Extends)
+		}
+	}
+
+	for id, fields := range tpl.Override {
+		// re-decode the override over a copy of the task so only the fields
+		// it sets change
+		overrideYAML, err := yaml.Marshal(fields)
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for i := range tasks {
+			if tasks[i].ID == id {
+				task := tasks[i]
+				if err := yaml.Unmarshal(overrideYAML, &task); err != nil {
+					return fmt.Errorf("override %s: %v", id, err)
+				}
+				tasks[i] = task
+				found = true
+				break
+			}
+			for j := range tasks[i].SubTasks {
+				if tasks[i].SubTasks[j].ID == id {
+					subTasks := append([]SubTask(nil), tasks[i].SubTasks...)
+					if err := yaml.Unmarshal(overrideYAML, &subTasks[j]); err != nil {
+						return fmt.Errorf("override %s: %v", id, err)
+					}
+					tasks[i].SubTasks = subTasks
+					found = true
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("override: no task with id %q in %s", id, tpl.Extends)
+		}
+	}
+
+	for _, task := range tpl.Tasks {
+		if task.InsertAfter == "" {
+			tasks = append(tasks, task)
+			continue
+		}
+
+		at := -1
+		for i := range tasks {
+			if tasks[i].ID == task.InsertAfter {
+				at = i + 1
+				break
+			}
+		}
+		if at < 0 {
+			return fmt.Errorf("%s: insert_after: no task with id %q", task.Title, task.InsertAfter)
+		}
+		tasks = append(tasks[:at:at], append([]Task{task}, tasks[at:]...)...)
+	}
+
+	tpl.Tasks = tasks
+	if tpl.Epic == nil {
+		tpl.Epic = base.Epic
+	}
+	if tpl.Vars == nil {
+		tpl.Vars = map[string]interface{}{}
+	}
+	mergeVars(tpl.Vars, base.Vars)
+	return nil
+}
+
+// templateRoot is a directory templates are loaded from, either the template
+// path or a template source.
+type templateRoot struct {
+	// name is the source name, empty for the template path
+	name string
+	// dir is the directory on disk, empty for sources that only exist in memory
+	dir  string
+	fsys fs.FS
+}
+
+func dirRoot(name string, dir string) templateRoot {
+	return templateRoot{name: name, dir: dir}
+}
+
+// ref returns how a template in this root is referenced, EG: team:runbook.yaml
+func (r templateRoot) ref(templatePath string) string {
+	if r.name == "" {
+		return templatePath
+	}
+	return r.name + ":" + templatePath
+}
+
+func (r templateRoot) readFile(templatePath string) ([]byte, error) {
+	if r.fsys != nil {
+		return fs.ReadFile(r.fsys, path.Clean(filepath.ToSlash(templatePath)))
+	}
+	return os.ReadFile(filepath.Join(r.dir, templatePath))
+}
+
+// walk calls fn with the path of every template in the root.
+func (r templateRoot) walk(fn func(templatePath string)) {
+	visit := func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		ext := strings.ToLower(path.Ext(d.Name()))
+		if !d.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			fn(p)
+		}
+		return nil
+	}
+
+	if r.fsys != nil {
+		fs.WalkDir(r.fsys, ".", visit)
+		return
+	}
+	filepath.WalkDir(r.dir, func(p string, d fs.DirEntry, err error) error {
+		rel, relErr := filepath.Rel(r.dir, p)
+		if relErr != nil {
+			return nil
+		}
+		return visit(rel, d, err)
+	})
+}
+
+func (r templateRoot) exists(templatePath string) bool {
+	if r.fsys != nil {
+		_, err := fs.Stat(r.fsys, path.Clean(filepath.ToSlash(templatePath)))
+		return err == nil
+	}
+	if r.dir == "" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(r.dir, templatePath))
+	return err == nil
+}
+
+// templateSearchPaths returns the template directories in order of precedence.
+// templatepath can be a list in the config file or flags, or a
+// os.PathListSeparator separated string, as in the TEMPLATEPATH environment
+// variable. It is read raw, viper.GetStringSlice would split strings on
+// spaces.
+func templateSearchPaths() []string {
+	var entries []string
+	switch value := viper.Get("templatepath").(type) {
+	case string:
+		entries = filepath.SplitList(value)
+	case []string:
+		entries = value
+	case []interface{}:
+		for _, entry := range value {
+			entries = append(entries, fmt.Sprint(entry))
+		}
+	}
+
+	var paths []string
+	seen := map[string]bool{}
+	for _, dir := range entries {
+		if expanded, err := homedir.Expand(dir); err == nil {
+			dir = expanded
+		}
+		if dir == "" || seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		paths = append(paths, dir)
+	}
+	return paths
+}
+
+func searchRoots() []templateRoot {
+	var roots []templateRoot
+	for _, dir := range templateSearchPaths() {
+		roots = append(roots, dirRoot("", dir))
+	}
+	return roots
+}
+
+// resolveTemplate finds the root and path a template reference points to.
+// References are source:path for templates from a configured template source,
+// builtin:name for the starter templates embedded in gojitzu, absolute paths, or paths looked up in current and then in each template
+// search path.
+func resolveTemplate(current templateRoot, templateRef string) (templateRoot, string, error) {
+	if name, templatePath, found := strings.Cut(templateRef, ":"); found {
+		if name == builtinSourceName {
+			return builtinRoot(), builtinTemplatePath(templatePath), nil
+		}
+		if _, ok := findTemplateSource(name); ok {
+			root, err := templateSourceRoot(name)
+			return root, templatePath, err
+		}
+	}
+
+	if filepath.IsAbs(templateRef) {
+		return dirRoot("", filepath.Dir(templateRef)), filepath.Base(templateRef), nil
+	}
+
+	for _, root := range append([]templateRoot{current}, searchRoots()...) {
+		if root.exists(templateRef) {
+			return root, templateRef, nil
+		}
+	}
+
+	return current, templateRef, fmt.Errorf("template %q not found in %s", templateRef, strings.Join(templateSearchPaths(), string(filepath.ListSeparator)))
+}
+
+// templateEntry is a template found by discoverTemplates.
+type templateEntry struct {
+	root templateRoot
+	path string
+	// shadowedBy is the directory of the template with the same name that
+	// takes precedence, empty when this one is used
+	shadowedBy string
+}
+
+// ref returns how the template is referenced. Shadowed templates can only be
+// referenced by their full path.
+func (e templateEntry) ref() string {
+	if e.shadowedBy != "" {
+		return filepath.Join(e.root.dir, e.path)
+	}
+	if e.root.name == builtinSourceName {
+		return e.root.ref(strings.TrimSuffix(e.path, path.Ext(e.path)))
+	}
+	return e.root.ref(e.path)
+}
+
+// discoverTemplates lists the templates on the search paths followed by the
+// templates of fetched template sources and the builtin templates.
+func discoverTemplates() []templateEntry {
+	var entries []templateEntry
+	owner := map[string]string{}
+
+	for _, root := range searchRoots() {
+		root.walk(func(templatePath string) {
+			entry := templateEntry{root: root, path: templatePath}
+			if dir, found := owner[templatePath]; found {
+				entry.shadowedBy = dir
+			} else {
+				owner[templatePath] = root.dir
+			}
+			entries = append(entries, entry)
+		})
+	}
+
+	for _, source := range Config.TemplateSources {
+		// only list sources that are already fetched
+		if _, err := os.Stat(templateSourceDir(source)); err != nil {
+			continue
+		}
+		root, err := templateSourceRoot(source.Name)
+		if err != nil {
+			continue
+		}
+		root.walk(func(templatePath string) {
+			entries = append(entries, templateEntry{root: root, path: templatePath})
+		})
+	}
+
+	builtin := builtinRoot()
+	builtin.walk(func(templatePath string) {
+		entries = append(entries, templateEntry{root: builtin, path: templatePath})
+	})
+
+	return entries
+}
+
+// load reads a template and appends the tasks of its includes. Templates
+// already included are skipped so include cycles terminate.
+func (tpl *Template) load(root templateRoot, templateRef string, includedSoFar ...map[string]bool) error {
+	root, templatePath, err := resolveTemplate(root, templateRef)
+	if err != nil {
+		return err
+	}
+
+	yamlFile, err := root.readFile(templatePath)
+	if err != nil {
+		return err
+	}
+
+	doc, warnings, err := migrateTemplate(yamlFile)
+	if err != nil {
+		return fmt.Errorf("%s: %v", root.ref(templatePath), err)
+	}
+	for _, warning := range warnings {
+		log.Printf("%s: %s", root.ref(templatePath), warning)
+	}
+
+	// decode the migrated document through yaml so the Template types only
+	// deal with the latest version
+	migrated, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	err = yaml.Unmarshal(migrated, tpl)
+	if err != nil {
+		return fmt.Errorf("%s: %v", root.ref(templatePath), err)
+	}
+
+	templateDir := ""
+	if root.dir != "" {
+		templateDir = filepath.Dir(filepath.Join(root.dir, templatePath))
+	}
+	for i := range tpl.Tasks {
+		tpl.Tasks[i].templateDir = templateDir
+		for j := range tpl.Tasks[i].SubTasks {
+			tpl.Tasks[i].SubTasks[j].templateDir = templateDir
+		}
+	}
+
+	included := make(map[string]bool)
+	for _, inc := range includedSoFar {
+		for key := range inc {
+			if _, found := included[key]; found {
+				continue
+			}
+			included[key] = true
+		}
+	}
+
+	if includedSoFar == nil {
+		included[root.ref(path.Clean(templatePath))] = true
+	}
+
+	if tpl.Extends != "" {
+		baseRoot, baseTemplatePath, err := resolveTemplate(root, tpl.Extends)
+		if err != nil {
+			return fmt.Errorf("%s: extends %s: %v", root.ref(templatePath), tpl.Extends, err)
+		}
+		baseKey := baseRoot.ref(path.Clean(baseTemplatePath))
+		if included[baseKey] {
+			return fmt.Errorf("%s: extends %s: template is already loaded", root.ref(templatePath), tpl.Extends)
+		}
+		included[baseKey] = true
+
+		var base Template
+		if err := base.load(baseRoot, tpl.Extends, included); err != nil {
+			return err
+		}
+		if err := tpl.extend(base); err != nil {
+			return fmt.Errorf("%s: %v", root.ref(templatePath), err)
+		}
+	} else if len(tpl.Override) > 0 || len(tpl.Remove) > 0 {
+		return fmt.Errorf("%s: override and remove need extends", root.ref(templatePath))
+	}
+
+	for _, includePath := range tpl.Includes {
+		includeRoot, includeTemplatePath, err := resolveTemplate(root, includePath)
+		if err != nil {
+			return fmt.Errorf("%s: include %s: %v", root.ref(templatePath), includePath, err)
+		}
+		includeKey := includeRoot.ref(path.Clean(includeTemplatePath))
+		if _, found := included[includeKey]; found {
+			continue
+		}
+		included[includeKey] = true
+
+		var includedTpl Template
+		if err := includedTpl.load(includeRoot, includePath, included); err != nil {
+			return err
+		}
+		for _, task := range includedTpl.Tasks {
+			task.includedFrom = append([]string{includeKey}, task.includedFrom...)
+			tpl.Tasks = append(tpl.Tasks, task)
+		}
+		if tpl.Vars == nil {
+			tpl.Vars = map[string]interface{}{}
+		}
+		mergeVars(tpl.Vars, includedTpl.Vars)
+	}
+
+	return nil
+}
@@ -5,13 +5,8 @@ import (
 	"github.com/andygrunwald/go-jira"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"io"
-	"io/fs"
-	"io/ioutil"
 	"log"
-	"path/filepath"
-	"strconv"
-	"strings"
+	"os"
 	"time"
 )
 
@@ -19,35 +14,184 @@ import (
 var tplCmd = &cobra.Command{
 	Use:   "tpl",
 	Short: "create issues based on templates",
-	Long:  `Create issues using templates`,
+	Long: `Create issues using templates.
+
+Issues are added to the epic with the Epic Link field in company-managed
+projects and with the parent field in team-managed (next-gen) projects. The
+project style is detected, --nextgen is no longer needed.
+
+Before creating anything, every issue is checked against the project's create
+metadata: issue types, required fields, fields missing from the create screen,
+allowed values and field lengths. All problems are reported together and no
+issue is created, as when the create metadata can not be read. --no-preflight
+skips the checks.
+
+Tasks and sub-tasks are created as Task and Sub-task unless they set type:,
+EG: type: Story. Types are matched by name or id, and can be mapped per
+project under issue_types in your config for renamed or translated types:
+
+  issue_types:
+    OPS:
+      task: Story
+      sub-task: Sous-tâche
+
+New epics take their title, description, Epic Name, colour, labels and
+components from the epic flags or the template's epic: block:
+
+  epic:
+    title: "{{.customer}} assessment"
+    color: ghx-label-4
+    labels: [assessment]
+
+Tasks and sub-tasks can set an assignee, reporter and watchers using an email,
+username, account id, or one of me, lead, component-lead and
+component-lead:NAME. Values can reference template variables, which are
+declared under vars: in the template and set with --var:
+
+  assignee: "{{.owner}}"
+
+Tasks can set due: and start: dates relative to the --anchor date or the epic
+due date, EG: "+3d", "+1bd" (business days), "epic-2w" or "next friday".
+Business days skip weekends and the holidays listed in your config.
+
+Tasks and sub-tasks with a when: expression are only created when it is true
+for the run variables, EG: when: 'env == "prod" && has_mobile'. Use --dry-run
+to see which tasks are created and which are skipped, and --interactive to
+fill in variables and pick the tasks to create from a checklist. Tasks can also
+be selected with --only-tags, --skip-tags, --only-ids, --skip-ids and
+--exclude-include, where tags match the tags: and labels: of a task. Sub-tasks
+follow their task unless they are selected or skipped themselves.
+
+A task with foreach: is created once per item of a list variable, an inline
+list or a matrix of lists, with the item available to its title, description,
+labels and sub-tasks as {{.item}} (or {{.item.host}} for a matrix).
+
+Tasks can set custom_fields: by their configured name or field id, and links:
+to other tasks of the run, referenced by their id:, or to existing issues:
+
+  - id: deploy
+    title: Deploy
+    custom_fields:
+      Story Points: 3
+    links:
+      - type: Blocks
+        target: verify
+
+A template can specialize another with extends:. It gets the tasks of the base
+template, minus the task ids listed under remove:, with the fields set under
+override: replaced, and its own tasks appended or placed with insert_after:
+
+  extends: base.yaml
+  remove: [verify]
+  override:
+    deploy:
+      labels: [team-a]
+  tasks:
+    - title: Smoke test
+      insert_after: deploy
+
+Templates declare their schema with version:. Older versions are migrated when
+loaded and deprecated keys are reported, use tpl migrate to update the files.`,
 	Run: func(cmd *cobra.Command, args []string) {
 
 		templates, _ := cmd.Flags().GetStringSlice("templates")
-		templatesPath := viper.GetString("templatepath")
+		varPairs, _ := cmd.Flags().GetStringArray("var")
+		vars, err := parseVars(varPairs)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		anchor, _ := cmd.Flags().GetString("anchor")
+		due, _ := cmd.Flags().GetString("duedate")
+		dates, err := newDateContext(time.Now(), Config.Holidays)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if anchor != "" {
+			if dates.anchor, err = dates.parse(anchor); err != nil {
+				log.Fatalf("Invalid --anchor: %v", err)
+			}
+		}
+		if due != "" {
+			if dates.epicDue, err = dates.parse(due); err != nil {
+				log.Fatalf("Invalid --duedate: %v", err)
+			}
+		}
+
 		var templateTasks []Task
+		var epicSpec EpicSpec
 		for _, templateName := range templates {
 			var template Template
-			template.load(templatesPath, templateName)
+			if err := template.load(templateRoot{}, templateName); err != nil {
+				log.Fatalf("Unable to load template: %v", err)
+			}
+			mergeVars(vars, template.Vars)
+			templateTasks = append(templateTasks, template.Tasks...)
+			epicSpec.merge(template.Epic)
+		}
+
+		interactive, _ := cmd.Flags().GetBool("interactive")
+		var prompt *prompter
+		if interactive {
+			prompt = newPrompter(os.Stdin, os.Stdout)
+			fixed, _ := parseVars(varPairs)
+			prompt.promptVars(vars, fixed)
+		}
 
-			for _, task := range template.Tasks {
-				fmt.Println(task.Title)
-				templateTasks = append(templateTasks, task)
+		templateTasks, skipped, err := expandTasks(templateTasks, vars)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if err := epicSpec.render(vars); err != nil {
+			log.Fatalf("epic: %v", err)
+		}
+		epicSpec.applyFlags(cmd)
+		epicKey, _ := cmd.Flags().GetString("epic")
+
+		filter := taskFilter{}
+		filter.onlyTags, _ = cmd.Flags().GetStringSlice("only-tags")
+		filter.skipTags, _ = cmd.Flags().GetStringSlice("skip-tags")
+		filter.onlyIDs, _ = cmd.Flags().GetStringSlice("only-ids")
+		filter.skipIDs, _ = cmd.Flags().GetStringSlice("skip-ids")
+		filter.excludeIncludes, _ = cmd.Flags().GetStringSlice("exclude-include")
+		if !filter.empty() {
+			var filtered []skippedTask
+			templateTasks, filtered = filter.apply(templateTasks)
+			skipped = append(skipped, filtered...)
+		}
+		if interactive {
+			var ok bool
+			if templateTasks, ok = prompt.pickTasks(templateTasks); !ok {
+				fmt.Println("Aborted")
+				return
 			}
 		}
+		printPlan(templateTasks, skipped)
+		if err := checkTemplateFields(templateTasks); err != nil {
+			log.Fatalf("%v", err)
+		}
+		if err := checkTemplateAttachments(templateTasks); err != nil {
+			log.Fatalf("%v", err)
+		}
 
 		if len(templateTasks) == 0 {
 			fmt.Println("Nothing to do")
 			return
 		}
 
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			return
+		}
+		if epicKey == "" && epicSpec.Title == "" {
+			log.Fatalf("Set --title, or title: in the template's epic: block, to create an epic, or add the tasks to an existing one with --epic")
+		}
+
 		//http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 
 		base := viper.GetString("baseurl")
 		username := viper.GetString("username")
 		password := viper.GetString("password")
 		projectKey := viper.GetString("project")
-		epicKey, _ := cmd.Flags().GetString("epic")
-		nextGen, _ := cmd.Flags().GetBool("nextgen")
 
 		tp := jira.BasicAuthTransport{
 			Username: username,
@@ -59,255 +203,113 @@ var tplCmd = &cobra.Command{
 			panic(err)
 		}
 
-		jiraProject, resp, err := jiraClient.Project.Get(projectKey)
+		jiraProject := getProject(jiraClient, projectKey)
+
+		resolver := newUserResolver(jiraClient, jiraProject.Key)
+		resolver.project = jiraProject
+		if err := checkTemplatePeople(resolver, templateTasks); err != nil {
+			log.Fatalf("%v", err)
+		}
+		if err := checkTemplateTypes(jiraProject, templateTasks, len(epicKey) == 0); err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		if len(epicKey) > 0 {
+			epic, resp, err := jiraClient.Issue.Get(epicKey, &jira.GetQueryOptions{Fields: "duedate"})
+			checkJiraError(resp, err)
+			dates.epicDue = time.Time(epic.Fields.Duedate)
+		}
+		needsStart, err := checkTemplateDates(dates, templateTasks)
 		if err != nil {
-			body, _ := io.ReadAll(resp.Body)
-			fmt.Println(string(body))
-			panic(err)
+			log.Fatalf("%v", err)
+		}
+		var startFieldID string
+		if needsStart {
+			if startFieldID = startDateFieldID(jiraClient); startFieldID == "" {
+				log.Fatalf("Unable to find the \"Start date\" field, add it to custom_fields in your config")
+			}
 		}
 
-		if !nextGen {
-			log.Println("Using normal Jira project workflow")
-			fieldList, _, _ := jiraClient.Field.GetList()
+		teamManaged := isTeamManaged(jiraClient, jiraProject.Key)
+		var fields epicFields
+		if teamManaged {
+			log.Println("Using team-managed Jira project workflow")
+		} else {
+			log.Println("Using company-managed Jira project workflow")
+			fields = findEpicFields(jiraClient)
+		}
 
-			var customFieldID string
-			for _, v := range fieldList {
-				if v.Name == "Epic Link" {
-					customFieldID = v.ID
-					break
-				}
+		prefix, _ := cmd.Flags().GetString("prefix")
+		builder := &tplIssueBuilder{
+			project:         jiraProject,
+			projectKey:      jiraProject.Key,
+			prefix:          prefix,
+			epicLinkFieldID: fields.link,
+			resolver:        resolver,
+			dates:           dates,
+			startFieldID:    startFieldID,
+		}
+
+		if len(epicKey) == 0 && !teamManaged {
+			if types, err := getCreateMeta(jiraClient, jiraProject.Key); err == nil {
+				fields = fields.onScreen(types, builder.issueType("", "epic"))
 			}
+		}
 
+		if noPreflight, _ := cmd.Flags().GetBool("no-preflight"); !noPreflight {
+			var epic *jira.Issue
 			if len(epicKey) == 0 {
-				title, _ := cmd.Flags().GetString("title")
-				description, _ := cmd.Flags().GetString("desc")
-				due, _ := cmd.Flags().GetString("duedate")
-				fmt.Println(title, description)
-				const dateFmt = "2006-01-02"
-				dueDateTime, _ := time.Parse(dateFmt, due)
-				i := jira.Issue{
-					Fields: &jira.IssueFields{
-						Description: description,
-						Type: jira.IssueType{
-							Name: "Epic",
-						},
-						Project: jira.Project{
-							Key: jiraProject.Key,
-						},
-						Summary: title,
-						Duedate: jira.Date(dueDateTime),
-					},
-				}
-				jiraEpic, res, err := jiraClient.Issue.Create(&i)
-				if err != nil {
-					body, _ := io.ReadAll(res.Body)
-					fmt.Println(string(body))
-					panic(err)
-				}
-				epicKey = jiraEpic.Key
+				i := epicSpec.issue(jiraProject.Key, builder.issueType("", "epic"), dates.epicDue, fields)
+				epic = &i
 			}
-
-			prefix, _ := cmd.Flags().GetString("prefix")
-			var newIssues []int
-			var newKeys []string
-			for _, task := range templateTasks {
-				title := task.Title
-				if prefix != "" && task.Prefixable {
-					title = fmt.Sprintf("%s %s", prefix, title)
-				}
-
-				i := jira.Issue{
-					Fields: &jira.IssueFields{
-						Description: task.Description,
-						Type: jira.IssueType{
-							Name: "Task",
-						},
-						Project: jira.Project{
-							Key: jiraProject.Key,
-						},
-						Summary: title,
-						Unknowns: map[string]interface{}{
-							customFieldID: epicKey,
-						},
-						Labels: task.Labels,
-					},
-				}
-				newIssue, resp, err := jiraClient.Issue.Create(&i)
-				if err != nil {
-					body, _ := io.ReadAll(resp.Body)
-					fmt.Println(string(body))
-					panic(err)
-				}
-
-				intID, err := strconv.Atoi(newIssue.ID)
-				fmt.Printf("Created %s\n", task.Title)
-				newIssues = append(newIssues, intID)
-				newKeys = append(newKeys, newIssue.Key)
-
-				if len(task.SubTasks) > 0 {
-					// Create subTasks
-					for _, subTask := range task.SubTasks {
-
-						title := subTask.Title
-						if prefix != "" && subTask.Prefixable {
-							title = fmt.Sprintf("%s %s", prefix, title)
-						}
-
-						i := jira.Issue{
-							Fields: &jira.IssueFields{
-								Description: subTask.Description,
-								Type: jira.IssueType{
-									Name: "Sub-task",
-								},
-								Project: jira.Project{
-									Key: jiraProject.Key,
-								},
-								Summary: title,
-								Labels:  subTask.Labels,
-								Parent: &jira.Parent{
-									ID:  newIssue.ID,
-									Key: newIssue.Key,
-								},
-							},
-						}
-						newSubTask, resp, err := jiraClient.Issue.Create(&i)
-						if err != nil {
-							body, _ := io.ReadAll(resp.Body)
-							fmt.Println(string(body))
-							panic(err)
-						}
-
-						//intID, err := strconv.Atoi(newSubTask.ID)
-						fmt.Printf("Created (%s) %s\n", newSubTask.Key, subTask.Title)
-						//newIssues = append(newIssues, intID)
-						//newKeys = append(newKeys, newSubTask.Key)
-					}
-
-				}
-
+			problems, err := preflight(jiraClient, builder, epic, epicKey, templateTasks)
+			if err != nil {
+				log.Fatalf("Unable to run the preflight checks, nothing was created (--no-preflight skips them): %v", err)
 			}
-
-			fmt.Printf("Done %s\n", epicKey)
-		} else {
-			log.Println("Using NextGen Jira project workflow")
-
-			var jiraEpic *jira.Issue
-			if len(epicKey) > 0 {
-				jiraEpic, _, _ = jiraClient.Issue.Get(epicKey, nil)
-			} else {
-				title, _ := cmd.Flags().GetString("title")
-				description, _ := cmd.Flags().GetString("desc")
-				due, _ := cmd.Flags().GetString("duedate")
-				fmt.Println(title, description)
-				const dateFmt = "2006-01-02"
-				dueDateTime, _ := time.Parse(dateFmt, due)
-				i := jira.Issue{
-					Fields: &jira.IssueFields{
-						Description: description,
-						Type: jira.IssueType{
-							Name: "Epic",
-						},
-						Project: jira.Project{
-							Key: jiraProject.Key,
-						},
-						Summary: title,
-						Duedate: jira.Date(dueDateTime),
-					},
-				}
-				jiraEpic, _, err = jiraClient.Issue.Create(&i)
-				if err != nil {
-					panic(err)
-				}
+			if epic != nil {
+				problems = append(problems, epicSpec.check(fields)...)
 			}
-
-			prefix, _ := cmd.Flags().GetString("prefix")
-			var newIssues []int
-			for _, task := range templateTasks {
-				title := task.Title
-				if prefix != "" && task.Prefixable {
-					title = fmt.Sprintf("%s %s", prefix, title)
-				}
-
-				i := jira.Issue{
-					Fields: &jira.IssueFields{
-						Description: task.Description,
-						Type: jira.IssueType{
-							Name: "Task",
-						},
-						Project: jira.Project{
-							Key: jiraProject.Key,
-						},
-						Summary: title,
-						Labels:  task.Labels,
-					},
-				}
-				newIssue, resp, err := jiraClient.Issue.Create(&i)
-				if err != nil {
-					body, _ := ioutil.ReadAll(resp.Body)
-					fmt.Println(string(body))
-					panic(err)
-				}
-
-				intID, err := strconv.Atoi(newIssue.ID)
-				fmt.Printf("Created %s\n", task.Title)
-				newIssues = append(newIssues, intID)
-
-				if len(task.SubTasks) > 0 {
-					// Create subTasks
-					for _, subTask := range task.SubTasks {
-
-						title := subTask.Title
-						if prefix != "" && subTask.Prefixable {
-							title = fmt.Sprintf("%s %s", prefix, title)
-						}
-
-						i := jira.Issue{
-							Fields: &jira.IssueFields{
-								Description: subTask.Description,
-								Type: jira.IssueType{
-									Name: "Sub-task",
-								},
-								Project: jira.Project{
-									Key: jiraProject.Key,
-								},
-								Summary: title,
-								Labels:  subTask.Labels,
-								Parent: &jira.Parent{
-									ID:  newIssue.ID,
-									Key: newIssue.Key,
-								},
-							},
-						}
-						newSubTask, resp, err := jiraClient.Issue.Create(&i)
-						if err != nil {
-							body, _ := io.ReadAll(resp.Body)
-							fmt.Println(string(body))
-							panic(err)
-						}
-
-						//intID, err := strconv.Atoi(newSubTask.ID)
-						fmt.Printf("Created (%s) %s\n", newSubTask.Key, subTask.Title)
-						//newIssues = append(newSubTask, intID)
-					}
-
+			if len(problems) > 0 {
+				for _, problem := range problems {
+					fmt.Println(problem)
 				}
+				log.Fatalf("Found %d problems, nothing was created", len(problems))
 			}
+		}
 
-			//add issues to epic
-			//for some reason, jira wouldn't let me set the epic link when creating issues. so this is what i am doing instead
-			epicPath := fmt.Sprintf("/rest/internal/simplified/1.0/projects/%s/issues/%s/children", jiraProject.ID, jiraEpic.ID)
-			epicIssues := make(map[string][]int)
-			epicIssues["issueIds"] = newIssues
+		if len(epicKey) == 0 {
+			fmt.Println(epicSpec.Title, epicSpec.Description)
+			i := epicSpec.issue(jiraProject.Key, builder.issueType("", "epic"), dates.epicDue, fields)
+			jiraEpic, resp, err := jiraClient.Issue.Create(&i)
+			checkJiraError(resp, err)
+			epicKey = jiraEpic.Key
+		}
 
-			req, err := jiraClient.NewRequest("POST", epicPath, epicIssues)
-			resp, err = jiraClient.Do(req, nil)
-			if err != nil {
-				body, _ := ioutil.ReadAll(resp.Body)
-				fmt.Println(string(body))
-				panic(err)
+		linker := newTaskLinker()
+		for _, task := range templateTasks {
+			i := builder.task(task, epicKey)
+			newIssue, resp, err := jiraClient.Issue.Create(&i)
+			checkJiraError(resp, err)
+
+			fmt.Printf("Created %s\n", task.Title)
+			addWatchers(jiraClient, resolver, newIssue.Key, task.Watchers)
+			uploadAttachments(jiraClient, newIssue.Key, task.templateDir, task.Attachments)
+			linker.add(task.ID, newIssue.Key, task.Links)
+
+			for _, subTask := range task.SubTasks {
+				i := builder.subTask(subTask, newIssue)
+				newSubTask, resp, err := jiraClient.Issue.Create(&i)
+				checkJiraError(resp, err)
+
+				fmt.Printf("Created (%s) %s\n", newSubTask.Key, subTask.Title)
+				addWatchers(jiraClient, resolver, newSubTask.Key, subTask.Watchers)
+				uploadAttachments(jiraClient, newSubTask.Key, subTask.templateDir, subTask.Attachments)
+				linker.add(subTask.ID, newSubTask.Key, subTask.Links)
 			}
-			fmt.Printf("Done %s\n", jiraEpic.Key)
 		}
+
+		linker.create(jiraClient)
+		fmt.Printf("Done %s\n", epicKey)
 	},
 }
 
@@ -316,27 +318,30 @@ func init() {
 	RootCmd.AddCommand(tplCmd)
 
 	tplCmd.PersistentFlags().BoolP("nextgen", "n", false, "specify next gen projects")
+	tplCmd.PersistentFlags().MarkDeprecated("nextgen", "the project style is detected automatically")
 
 	tplCmd.Flags().StringSliceP("templates", "t", []string{}, "templates to use")
-	tplCmd.RegisterFlagCompletionFunc("templates", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		templatesPath := viper.GetString("templatepath")
-		var templates []string
-		filepath.WalkDir(templatesPath, func(path string, d fs.DirEntry, err error) error {
-			ext := strings.ToLower(filepath.Ext(d.Name()))
-			if !d.IsDir() && (ext == ".yaml" || ext == ".yml") {
-				templatePath, _ := filepath.Rel(templatesPath, path)
-				templates = append(templates, templatePath)
-			}
-			return nil
-		})
-		return templates, cobra.ShellCompDirectiveDefault
-	})
+	tplCmd.RegisterFlagCompletionFunc("templates", completeTemplates)
 
-	tplCmd.Flags().StringP("duedate", "d", "", "due date")
+	tplCmd.Flags().StringP("duedate", "d", "", "due date for the new epic, EG: 2021-06-30, next friday, +2w")
+	tplCmd.Flags().String("anchor", "", "date relative task dates are counted from, defaults to today")
 	tplCmd.Flags().StringP("desc", "D", "", "Description")
 	tplCmd.Flags().StringP("epic", "e", "", "epic key to add issues to existing epic")
 	tplCmd.Flags().StringP("title", "T", "", "Title for the new epic")
+	tplCmd.Flags().String("epic-name", "", "Epic Name of the new epic in company-managed projects, defaults to the title")
+	tplCmd.Flags().String("epic-color", "", "colour of the new epic, EG: ghx-label-4 or 4")
+	tplCmd.Flags().StringSlice("epic-label", []string{}, "labels of the new epic")
+	tplCmd.Flags().StringSlice("epic-component", []string{}, "components of the new epic")
 	tplCmd.Flags().String("prefix", "", "prefix for tasks that are prefixable")
+	tplCmd.Flags().StringArray("var", []string{}, "template variable, EG: --var owner=jdoe@example.com")
+	tplCmd.Flags().Bool("dry-run", false, "print the tasks that would be created and exit")
+	tplCmd.Flags().StringSlice("only-tags", []string{}, "only create tasks with one of these tags or labels")
+	tplCmd.Flags().StringSlice("skip-tags", []string{}, "skip tasks with one of these tags or labels")
+	tplCmd.Flags().StringSlice("only-ids", []string{}, "only create the tasks with these ids")
+	tplCmd.Flags().StringSlice("skip-ids", []string{}, "skip the tasks with these ids")
+	tplCmd.Flags().StringSlice("exclude-include", []string{}, "skip the tasks of an included template, EG: common.yaml")
+	tplCmd.Flags().Bool("no-preflight", false, "skip checking the issues against the project's create metadata")
+	tplCmd.Flags().BoolP("interactive", "i", false, "pick the tasks to create and fill in variables before creating them")
 
 	// Here you will define your flags and configuration settings.
 
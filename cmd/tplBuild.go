@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+// tplIssueBuilder builds the issues of a tpl run, so they can be checked
+// before any of them is created.
+type tplIssueBuilder struct {
+	project    *jira.Project
+	projectKey string
+	prefix     string
+	// epicLinkFieldID is the Epic Link field, empty to add tasks to the epic
+	// through parent
+	epicLinkFieldID string
+	resolver        *userResolver
+	dates           *dateContext
+	startFieldID    string
+}
+
+func (b *tplIssueBuilder) title(title string, prefixable bool) string {
+	if b.prefix != "" && prefixable {
+		return fmt.Sprintf("%s %s", b.prefix, title)
+	}
+	return title
+}
+
+// issueType returns the type to create an issue with, by id. Types are
+// checked with checkTemplateTypes first.
+func (b *tplIssueBuilder) issueType(name string, def string) jira.IssueType {
+	issueType, err := resolveIssueType(b.project, taskType(name, def))
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	return jira.IssueType{ID: issueType.ID}
+}
+
+func (b *tplIssueBuilder) task(task Task, epicKey string) jira.Issue {
+	i := jira.Issue{
+		Fields: &jira.IssueFields{
+			Description: task.Description,
+			Type:        b.issueType(task.Type, "task"),
+			Project: jira.Project{
+				Key: b.projectKey,
+			},
+			Summary: b.title(task.Title, task.Prefixable),
+			Labels:  task.Labels,
+		},
+	}
+	// team-managed projects, and company-managed ones that retired the Epic
+	// Link field, add issues to epics through parent
+	if b.epicLinkFieldID != "" {
+		i.Fields.Unknowns = map[string]interface{}{
+			b.epicLinkFieldID: epicKey,
+		}
+	} else {
+		i.Fields.Parent = &jira.Parent{Key: epicKey}
+	}
+	setPeople(b.resolver, i.Fields, task.Assignee, task.Reporter)
+	setDates(b.dates, i.Fields, task.Due, task.Start, b.startFieldID)
+	setCustomFields(i.Fields, task.CustomFields)
+	return i
+}
+
+func (b *tplIssueBuilder) subTask(subTask SubTask, parent *jira.Issue) jira.Issue {
+	i := jira.Issue{
+		Fields: &jira.IssueFields{
+			Description: subTask.Description,
+			Type:        b.issueType(subTask.Type, "sub-task"),
+			Project: jira.Project{
+				Key: b.projectKey,
+			},
+			Summary: b.title(subTask.Title, subTask.Prefixable),
+			Labels:  subTask.Labels,
+			Parent: &jira.Parent{
+				ID:  parent.ID,
+				Key: parent.Key,
+			},
+		},
+	}
+	setPeople(b.resolver, i.Fields, subTask.Assignee, subTask.Reporter)
+	setDates(b.dates, i.Fields, subTask.Due, subTask.Start, b.startFieldID)
+	setCustomFields(i.Fields, subTask.CustomFields)
+	return i
+}
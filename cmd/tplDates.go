@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+// checkTemplateDates resolves every task date so mistakes are reported before
+// any issue is created. It reports whether any task sets a start date.
+func checkTemplateDates(dates *dateContext, tasks []Task) (bool, error) {
+	needsStart := false
+	check := func(title string, due string, start string) error {
+		for _, expr := range []string{due, start} {
+			if expr == "" {
+				continue
+			}
+			if _, err := dates.parse(expr); err != nil {
+				return fmt.Errorf("%s: %v", title, err)
+			}
+		}
+		needsStart = needsStart || start != ""
+		return nil
+	}
+
+	for _, task := range tasks {
+		if err := check(task.Title, task.Due, task.Start); err != nil {
+			return false, err
+		}
+		for _, subTask := range task.SubTasks {
+			if err := check(subTask.Title, subTask.Due, subTask.Start); err != nil {
+				return false, err
+			}
+		}
+	}
+	return needsStart, nil
+}
+
+// setDates sets the due date and start date of a new issue.
+func setDates(dates *dateContext, fields *jira.IssueFields, due string, start string, startFieldID string) {
+	if due != "" {
+		d, err := dates.parse(due)
+		if err != nil {
+			log.Fatalf("Invalid due date %q: %v", due, err)
+		}
+		fields.Duedate = jira.Date(d)
+	}
+	if start != "" {
+		d, err := dates.parse(start)
+		if err != nil {
+			log.Fatalf("Invalid start date %q: %v", start, err)
+		}
+		if fields.Unknowns == nil {
+			fields.Unknowns = map[string]interface{}{}
+		}
+		fields.Unknowns[startFieldID] = jira.Date(d)
+	}
+}
+
+// startDateFieldID finds the "Start date" field, which is a custom field in Jira.
+func startDateFieldID(jiraClient *jira.Client) string {
+	if fieldID, ok := Config.CustomFieldID("Start date"); ok {
+		return fieldID
+	}
+
+	for _, field := range getFields(jiraClient) {
+		if strings.EqualFold(field.Name, "Start date") {
+			return field.ID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/spf13/cobra"
+)
+
+// EpicSpec describes the epic a run creates when no --epic is given. Flags
+// override the values of the template's epic: block.
+type EpicSpec struct {
+	Title       string `yaml:"title,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	// Name is the Epic Name of company-managed projects, the title when empty
+	Name string `yaml:"name,omitempty"`
+	// Color is an epic colour such as ghx-label-3, or just 3
+	Color      string   `yaml:"color,omitempty"`
+	Labels     []string `yaml:"labels,omitempty"`
+	Components []string `yaml:"components,omitempty"`
+}
+
+// merge fills the values not set yet from other.
+func (e *EpicSpec) merge(other *EpicSpec) {
+	if other == nil {
+		return
+	}
+	for _, field := range []struct{ dst, src *string }{
+		{&e.Title, &other.Title},
+		{&e.Description, &other.Description},
+		{&e.Name, &other.Name},
+		{&e.Color, &other.Color},
+	} {
+		if *field.dst == "" {
+			*field.dst = *field.src
+		}
+	}
+	if e.Labels == nil {
+		e.Labels = other.Labels
+	}
+	if e.Components == nil {
+		e.Components = other.Components
+	}
+}
+
+// applyFlags overrides the spec with the epic flags of the tpl command.
+func (e *EpicSpec) applyFlags(cmd *cobra.Command) {
+	for flag, value := range map[string]*string{
+		"title":      &e.Title,
+		"desc":       &e.Description,
+		"epic-name":  &e.Name,
+		"epic-color": &e.Color,
+	} {
+		if cmd.Flags().Changed(flag) {
+			*value, _ = cmd.Flags().GetString(flag)
+		}
+	}
+	if cmd.Flags().Changed("epic-label") {
+		e.Labels, _ = cmd.Flags().GetStringSlice("epic-label")
+	}
+	if cmd.Flags().Changed("epic-component") {
+		e.Components, _ = cmd.Flags().GetStringSlice("epic-component")
+	}
+}
+
+func (e *EpicSpec) render(vars map[string]interface{}) error {
+	err := renderStrings(vars, &e.Title, &e.Description, &e.Name, &e.Color)
+	if err != nil {
+		return err
+	}
+	for _, list := range []*[]string{&e.Labels, &e.Components} {
+		if *list, err = renderList(vars, *list); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// epicFields are the ids of the custom fields company-managed projects use
+// for epics, empty when the server does not have them.
+type epicFields struct {
+	link  string
+	name  string
+	color string
+}
+
+func findEpicFields(jiraClient *jira.Client) epicFields {
+	var fields epicFields
+	for _, v := range getFields(jiraClient) {
+		switch v.Name {
+		case "Epic Link":
+			fields.link = v.ID
+		case "Epic Name":
+			fields.name = v.ID
+		case "Epic Colour", "Epic Color":
+			fields.color = v.ID
+		}
+	}
+	return fields
+}
+
+// onScreen drops the Epic Name and Epic Colour fields the create screen of
+// the epic issue type does not have, Cloud projects can remove them. The
+// Epic Link is kept, tasks set it.
+func (f epicFields) onScreen(types map[string]*createMetaType, issueType jira.IssueType) epicFields {
+	for _, t := range types {
+		if t.ID != issueType.ID {
+			continue
+		}
+		if _, ok := t.Fields[f.name]; !ok {
+			f.name = ""
+		}
+		if _, ok := t.Fields[f.color]; !ok {
+			f.color = ""
+		}
+	}
+	return f
+}
+
+var (
+	epicColorRe      = regexp.MustCompile(`^\d+$`)
+	epicColorLabelRe = regexp.MustCompile(`^ghx-label-([1-9]|1[0-4])$`)
+)
+
+// epicColor turns a colour number into the ghx-label name Jira expects.
+func epicColor(color string) string {
+	color = strings.ToLower(color)
+	if epicColorRe.MatchString(color) {
+		color = "ghx-label-" + color
+	}
+	return color
+}
+
+// check returns the problems Jira would reject the epic details for, which
+// the create metadata does not describe.
+func (e *EpicSpec) check(fields epicFields) []string {
+	var problems []string
+	name := e.Name
+	if name == "" {
+		name = e.Title
+	}
+	if fields.name != "" && len([]rune(name)) > 255 {
+		problems = append(problems, fmt.Sprintf("epic: Epic Name is %d characters long, the limit is 255", len([]rune(name))))
+	}
+	if e.Color != "" {
+		if fields.color == "" {
+			problems = append(problems, "epic: the epic create screen has no Epic Colour field, remove the epic colour")
+		} else if !epicColorLabelRe.MatchString(epicColor(e.Color)) {
+			problems = append(problems, fmt.Sprintf("epic: invalid colour %q, expected 1 to 14 or ghx-label-1 to ghx-label-14", e.Color))
+		}
+	}
+	return problems
+}
+
+// issue returns the epic to create.
+func (e *EpicSpec) issue(projectKey string, issueType jira.IssueType, due time.Time, fields epicFields) jira.Issue {
+	i := jira.Issue{
+		Fields: &jira.IssueFields{
+			Description: e.Description,
+			Type:        issueType,
+			Project: jira.Project{
+				Key: projectKey,
+			},
+			Summary: e.Title,
+			Duedate: jira.Date(due),
+			Labels:  e.Labels,
+		},
+	}
+	for _, component := range e.Components {
+		i.Fields.Components = append(i.Fields.Components, &jira.Component{Name: component})
+	}
+
+	unknowns := map[string]interface{}{}
+	if fields.name != "" {
+		name := e.Name
+		if name == "" {
+			name = e.Title
+		}
+		unknowns[fields.name] = name
+	}
+	if fields.color != "" && e.Color != "" {
+		unknowns[fields.color] = epicColor(e.Color)
+	}
+	if len(unknowns) > 0 {
+		i.Fields.Unknowns = unknowns
+	}
+	return i
+}
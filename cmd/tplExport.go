@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// tplExportCmd represents the tpl export command
+var tplExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export an epic's issues as a template",
+	Long: `Export the issues and sub-tasks of an epic as a template, with their
+summaries, descriptions, labels, links and the custom fields listed in your
+config:
+
+  gojitzu tpl export -e OPS-1 > runbook.yaml
+
+--strip-prefix removes a prefix from summaries and marks those tasks
+prefixable, so tpl --prefix can set a new one. --param replaces every
+occurrence of a string with a template variable, defaulting to that string:
+
+  gojitzu tpl export -e OPS-1 --strip-prefix "[acme]" --param customer=Acme`,
+	Run: func(cmd *cobra.Command, args []string) {
+		epicKey, _ := cmd.Flags().GetString("epic")
+		if epicKey == "" {
+			log.Fatalf("--epic is required")
+		}
+		stripPrefix, _ := cmd.Flags().GetString("strip-prefix")
+		paramPairs, _ := cmd.Flags().GetStringArray("param")
+		name, _ := cmd.Flags().GetString("name")
+
+		params, err := parseVars(paramPairs)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		jiraClient := newJiraClient()
+		epic, resp, err := jiraClient.Issue.Get(epicKey, nil)
+		checkJiraError(resp, err)
+
+		children := GetAllIssues(jiraClient, epicChildrenJQL(epic.Key)+" ORDER BY created ASC")
+		var parentKeys []string
+		for _, child := range children {
+			if len(child.Fields.Subtasks) > 0 {
+				parentKeys = append(parentKeys, child.Key)
+			}
+		}
+		subTasks := map[string]jira.Issue{}
+		if len(parentKeys) > 0 {
+			for _, sub := range GetAllIssues(jiraClient, fmt.Sprintf("parent in (%s)", strings.Join(parentKeys, ","))) {
+				subTasks[sub.Key] = sub
+			}
+		}
+
+		exporter := newTemplateExporter(epic.Key, stripPrefix, params)
+		template := exporter.export(children, subTasks)
+		template.Name = name
+		if template.Name == "" {
+			template.Name = epic.Fields.Summary
+		}
+		template.Description = fmt.Sprintf("Exported from %s", epic.Key)
+
+		out, err := yaml.Marshal(template)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		os.Stdout.Write(out)
+	},
+}
+
+// templateExporter turns issues into template tasks.
+type templateExporter struct {
+	epicKey     string
+	stripPrefix string
+	params      map[string]interface{}
+	// textReplacer escapes {{ and replaces parameter values with their
+	// variable in a single pass
+	textReplacer *strings.Replacer
+	// exported are the keys of the exported issues
+	exported map[string]bool
+	// ids are the task ids of exported issues other issues link to
+	ids map[string]string
+}
+
+func newTemplateExporter(epicKey string, stripPrefix string, params map[string]interface{}) *templateExporter {
+	e := &templateExporter{
+		epicKey:     epicKey,
+		stripPrefix: stripPrefix,
+		params:      params,
+		exported:    map[string]bool{},
+		ids:         map[string]string{},
+	}
+
+	// longest values first, the replacer prefers the earlier of two matches
+	// at the same position, so overlapping values get the most specific
+	// variable
+	replacements := [][2]string{{"{{", `{{"{{"}}`}}
+	for name, value := range params {
+		if value := fmt.Sprint(value); value != "" {
+			replacements = append(replacements, [2]string{value, "{{." + name + "}}"})
+		}
+	}
+	sort.Slice(replacements, func(i, j int) bool {
+		if len(replacements[i][0]) != len(replacements[j][0]) {
+			return len(replacements[i][0]) > len(replacements[j][0])
+		}
+		return replacements[i][1] < replacements[j][1]
+	})
+	var oldNew []string
+	for _, r := range replacements {
+		oldNew = append(oldNew, r[0], r[1])
+	}
+	e.textReplacer = strings.NewReplacer(oldNew...)
+	return e
+}
+
+func (e *templateExporter) export(children []jira.Issue, subTasks map[string]jira.Issue) *Template {
+	var issues []jira.Issue
+	for _, child := range children {
+		issues = append(issues, child)
+		for _, sub := range child.Fields.Subtasks {
+			if full, ok := subTasks[sub.Key]; ok {
+				issues = append(issues, full)
+			}
+		}
+	}
+	e.assignIDs(issues)
+
+	template := &Template{Version: latestTemplateVersion}
+	if len(e.params) > 0 {
+		template.Vars = e.params
+	}
+	for _, child := range children {
+		task := Task{ID: e.ids[child.Key], Type: exportType(child, "Task"), Labels: e.labels(child)}
+		task.Title, task.Prefixable = e.title(child.Fields.Summary)
+		task.Description = e.text(child.Fields.Description)
+		task.CustomFields = e.customFields(child)
+		task.Links = e.links(child)
+
+		for _, sub := range child.Fields.Subtasks {
+			full, ok := subTasks[sub.Key]
+			if !ok {
+				continue
+			}
+			subTask := SubTask{ID: e.ids[full.Key], Type: exportType(full, "Sub-task"), Labels: e.labels(full)}
+			subTask.Title, subTask.Prefixable = e.title(full.Fields.Summary)
+			subTask.Description = e.text(full.Fields.Description)
+			subTask.CustomFields = e.customFields(full)
+			subTask.Links = e.links(full)
+			task.SubTasks = append(task.SubTasks, subTask)
+		}
+		template.Tasks = append(template.Tasks, task)
+	}
+	return template
+}
+
+// exportType returns the issue type of an issue unless it is the default.
+func exportType(issue jira.Issue, def string) string {
+	if issue.Fields.Type.Name == "" || strings.EqualFold(issue.Fields.Type.Name, def) {
+		return ""
+	}
+	return issue.Fields.Type.Name
+}
+
+var slugRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// assignIDs gives the exported issues that are link targets a task id based
+// on their summary.
+func (e *templateExporter) assignIDs(issues []jira.Issue) {
+	for _, issue := range issues {
+		e.exported[issue.Key] = true
+	}
+
+	used := map[string]bool{}
+	for _, issue := range issues {
+		for _, link := range issue.Fields.IssueLinks {
+			if link.OutwardIssue == nil || !e.exported[link.OutwardIssue.Key] || e.ids[link.OutwardIssue.Key] != "" {
+				continue
+			}
+
+			var summary string
+			for _, target := range issues {
+				if target.Key == link.OutwardIssue.Key {
+					summary, _ = e.title(target.Fields.Summary)
+					break
+				}
+			}
+			base := strings.Trim(slugRe.ReplaceAllString(strings.ToLower(summary), "-"), "-")
+			if base == "" {
+				base = "task"
+			}
+			id := base
+			for n := 2; used[id]; n++ {
+				id = fmt.Sprintf("%s-%d", base, n)
+			}
+			used[id] = true
+			e.ids[link.OutwardIssue.Key] = id
+		}
+	}
+}
+
+// text escapes template actions in Jira text, EG: {{monospace}}, and replaces
+// parameter values with their variable.
+func (e *templateExporter) text(s string) string {
+	return e.textReplacer.Replace(s)
+}
+
+func (e *templateExporter) title(summary string) (string, bool) {
+	prefixable := false
+	if e.stripPrefix != "" && strings.HasPrefix(summary, e.stripPrefix) {
+		summary = strings.TrimLeft(strings.TrimPrefix(summary, e.stripPrefix), " :-")
+		prefixable = true
+	}
+	return e.text(summary), prefixable
+}
+
+func (e *templateExporter) labels(issue jira.Issue) []string {
+	var labels []string
+	for _, label := range issue.Fields.Labels {
+		labels = append(labels, e.text(label))
+	}
+	return labels
+}
+
+// customFields exports the custom fields listed in the config, leaving out
+// the link to the epic.
+func (e *templateExporter) customFields(issue jira.Issue) map[string]interface{} {
+	fields := map[string]interface{}{}
+	for _, field := range Config.CustomFields {
+		value, ok := issue.Fields.Unknowns[field.JiraField]
+		if !ok || value == nil || value == e.epicKey {
+			continue
+		}
+		value = exportFieldValue(value)
+		if s, ok := value.(string); ok {
+			value = e.text(s)
+		}
+		fields[field.Name] = value
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// exportFieldValue reduces option, user and similar objects to the property
+// Jira accepts when setting them.
+func exportFieldValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, key := range []string{"value", "key", "accountId", "name", "id"} {
+			if val, ok := v[key]; ok {
+				return map[string]interface{}{key: val}
+			}
+		}
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, val := range v {
+			list[i] = exportFieldValue(val)
+		}
+		return list
+	}
+	return value
+}
+
+// links exports outward links, and inward links from issues outside the
+// export. Inward links between exported issues are the outward link of the
+// other issue.
+func (e *templateExporter) links(issue jira.Issue) []TaskLink {
+	var links []TaskLink
+	for _, link := range issue.Fields.IssueLinks {
+		switch {
+		case link.OutwardIssue != nil:
+			links = append(links, TaskLink{Type: link.Type.Name, Target: e.target(link.OutwardIssue.Key)})
+		case link.InwardIssue != nil:
+			if e.exported[link.InwardIssue.Key] {
+				continue
+			}
+			links = append(links, TaskLink{Type: link.Type.Name, Target: link.InwardIssue.Key, Inward: true})
+		}
+	}
+	return links
+}
+
+func (e *templateExporter) target(issueKey string) string {
+	if id := e.ids[issueKey]; id != "" {
+		return id
+	}
+	return issueKey
+}
+
+func init() {
+	tplCmd.AddCommand(tplExportCmd)
+	tplExportCmd.Flags().StringP("epic", "e", "", "epic to export")
+	tplExportCmd.Flags().String("strip-prefix", "", "prefix to remove from summaries, the tasks are marked prefixable")
+	tplExportCmd.Flags().StringArray("param", []string{}, "replace a string with a variable, EG: --param customer=Acme")
+	tplExportCmd.Flags().String("name", "", "template name, defaults to the epic summary")
+}
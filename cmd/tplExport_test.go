@@ -0,0 +1,35 @@
+package cmd
+
+import "testing"
+
+func TestExportTextRoundTrips(t *testing.T) {
+	// values that are part of variable names, of other values and of the
+	// {{ escape must not rewrite what earlier replacements inserted
+	params := map[string]interface{}{
+		"name":    "Sam",
+		"release": "a",
+		"brace":   "{",
+		"service": "Sam-api",
+	}
+	e := newTemplateExporter("OPS-1", "", params)
+
+	for _, text := range []string{
+		"Deploy Sam-api for Sam",
+		"Run {{kubectl get pods}} as a name",
+		"{ and {{ and }}",
+	} {
+		exported := e.text(text)
+		got, err := expandString(exported, params)
+		if err != nil {
+			t.Errorf("%q exported as %q: %v", text, exported, err)
+			continue
+		}
+		if got != text {
+			t.Errorf("%q exported as %q renders %q", text, exported, got)
+		}
+	}
+
+	if got := e.text("Deploy Sam-api for Sam"); got != "Deploy {{.service}} for {{.name}}" {
+		t.Errorf("unexpected export %q", got)
+	}
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+// TaskLink links the issue created for a task to another task of the run, by
+// its id, or to an existing issue, by its key:
+//
+//	links:
+//	  - type: Blocks
+//	    target: deploy
+//	  - type: Relates
+//	    target: OPS-12
+//
+// A link reads from the task to the target, EG: the task blocks deploy. Set
+// inward to link the other way around.
+type TaskLink struct {
+	Type   string `yaml:"type"`
+	Target string `yaml:"target"`
+	Inward bool   `yaml:"inward,omitempty"`
+}
+
+var issueKeyRe = regexp.MustCompile(`^[A-Z][A-Z0-9_]*-\d+$`)
+
+// checkTemplateFields makes sure task ids are unique, custom fields are known
+// and links point to a task id or an issue key before anything is created.
+func checkTemplateFields(tasks []Task) error {
+	ids := map[string]bool{}
+	addID := func(id string) error {
+		if id == "" {
+			return nil
+		}
+		if ids[id] {
+			return fmt.Errorf("task id %q is used more than once", id)
+		}
+		ids[id] = true
+		return nil
+	}
+	for _, task := range tasks {
+		if err := addID(task.ID); err != nil {
+			return err
+		}
+		for _, subTask := range task.SubTasks {
+			if err := addID(subTask.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	check := func(title string, customFields map[string]interface{}, links []TaskLink) error {
+		for name := range customFields {
+			if _, ok := Config.CustomFieldID(name); !ok {
+				return fmt.Errorf("%s: unknown custom field %q, add it to custom_fields in your config", title, name)
+			}
+		}
+		for _, link := range links {
+			if link.Type == "" {
+				return fmt.Errorf("%s: link to %s has no type", title, link.Target)
+			}
+			if !ids[link.Target] && !issueKeyRe.MatchString(link.Target) {
+				return fmt.Errorf("%s: link target %q is not a task id or an issue key", title, link.Target)
+			}
+		}
+		return nil
+	}
+	for _, task := range tasks {
+		if err := check(task.Title, task.CustomFields, task.Links); err != nil {
+			return err
+		}
+		for _, subTask := range task.SubTasks {
+			if err := check(subTask.Title, subTask.CustomFields, subTask.Links); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setCustomFields sets the custom fields of a task, by configured name or id.
+func setCustomFields(fields *jira.IssueFields, customFields map[string]interface{}) {
+	if len(customFields) == 0 {
+		return
+	}
+	if fields.Unknowns == nil {
+		fields.Unknowns = map[string]interface{}{}
+	}
+	for name, value := range customFields {
+		id, ok := Config.CustomFieldID(name)
+		if !ok {
+			log.Fatalf("Unknown custom field %q", name)
+		}
+		fields.Unknowns[id] = jsonValue(value)
+	}
+}
+
+// jsonValue converts the maps yaml decodes into ones that encode as JSON.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for key, val := range v {
+			m[fmt.Sprint(key)] = jsonValue(val)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, val := range v {
+			list[i] = jsonValue(val)
+		}
+		return list
+	}
+	return value
+}
+
+type pendingLink struct {
+	issueKey string
+	link     TaskLink
+}
+
+// taskLinker collects the links of created tasks and creates them once every
+// task of the run, and so every link target, exists.
+type taskLinker struct {
+	keys  map[string]string
+	links []pendingLink
+}
+
+func newTaskLinker() *taskLinker {
+	return &taskLinker{keys: map[string]string{}}
+}
+
+// add records the issue created for a task.
+func (l *taskLinker) add(id string, issueKey string, links []TaskLink) {
+	if id != "" {
+		l.keys[id] = issueKey
+	}
+	for _, link := range links {
+		l.links = append(l.links, pendingLink{issueKey: issueKey, link: link})
+	}
+}
+
+func (l *taskLinker) create(jiraClient *jira.Client) {
+	for _, pending := range l.links {
+		target := pending.link.Target
+		if key, ok := l.keys[target]; ok {
+			target = key
+		}
+
+		// the inward issue of a new link is the one the outward description
+		// applies to, EG: inward blocks outward
+		from, to := pending.issueKey, target
+		if pending.link.Inward {
+			from, to = to, from
+		}
+		resp, err := jiraClient.Issue.AddLink(&jira.IssueLink{
+			Type:         jira.IssueLinkType{Name: pending.link.Type},
+			InwardIssue:  &jira.Issue{Key: from},
+			OutwardIssue: &jira.Issue{Key: to},
+		})
+		checkJiraError(resp, err)
+		fmt.Printf("Linked %s %s %s\n", from, pending.link.Type, to)
+	}
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"path"
+	"strings"
+)
+
+// taskFilter selects the tasks of a run with the --only-tags, --skip-tags,
+// --only-ids, --skip-ids and --exclude-include flags. Tags match both the
+// tags: and the labels: of a task.
+type taskFilter struct {
+	onlyTags        []string
+	skipTags        []string
+	onlyIDs         []string
+	skipIDs         []string
+	excludeIncludes []string
+}
+
+func (f taskFilter) empty() bool {
+	return len(f.onlyTags) == 0 && len(f.skipTags) == 0 && len(f.onlyIDs) == 0 && len(f.skipIDs) == 0 && len(f.excludeIncludes) == 0
+}
+
+// apply returns the selected tasks. Sub-tasks follow their task unless they
+// are targeted themselves: a sub-task matching --only-tags or --only-ids is
+// kept, with its task, and one matching --skip-tags or --skip-ids is dropped.
+func (f taskFilter) apply(tasks []Task) ([]Task, []skippedTask) {
+	var selected []Task
+	var skipped []skippedTask
+
+	for _, task := range tasks {
+		excluded := f.excludedInclude(task.includedFrom)
+		reason := excluded
+		if reason == "" {
+			reason = f.skipReason(task.ID, task.Tags, task.Labels)
+		}
+		if reason == "" && !f.selectedByOnly(task.ID, task.Tags, task.Labels) {
+			reason = "not selected by --only-tags or --only-ids"
+		}
+
+		var subTasks []SubTask
+		var skippedSubTasks []skippedTask
+		for _, subTask := range task.SubTasks {
+			subReason := f.skipReason(subTask.ID, subTask.Tags, subTask.Labels)
+			if subReason == "" && reason != "" && (excluded != "" || !f.targeted(subTask.ID, subTask.Tags, subTask.Labels)) {
+				subReason = reason
+			}
+			if subReason != "" {
+				skippedSubTasks = append(skippedSubTasks, skippedTask{Title: subTask.Title, Parent: task.Title, Reason: subReason})
+				continue
+			}
+			subTasks = append(subTasks, subTask)
+		}
+
+		if reason != "" && len(subTasks) == 0 {
+			skipped = append(skipped, skippedTask{Title: task.Title, Reason: reason})
+			continue
+		}
+		task.SubTasks = subTasks
+		selected = append(selected, task)
+		skipped = append(skipped, skippedSubTasks...)
+	}
+
+	return dropUnpickedLinks(tasks, selected), skipped
+}
+
+// targeted reports whether --only-tags or --only-ids name the task.
+func (f taskFilter) targeted(id string, tags []string, labels []string) bool {
+	if id != "" && containsFold(f.onlyIDs, id) {
+		return true
+	}
+	for _, tag := range append(append([]string{}, tags...), labels...) {
+		if containsFold(f.onlyTags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f taskFilter) selectedByOnly(id string, tags []string, labels []string) bool {
+	if len(f.onlyTags) == 0 && len(f.onlyIDs) == 0 {
+		return true
+	}
+	return f.targeted(id, tags, labels)
+}
+
+func (f taskFilter) skipReason(id string, tags []string, labels []string) string {
+	if id != "" && containsFold(f.skipIDs, id) {
+		return "--skip-ids " + id
+	}
+	for _, tag := range append(append([]string{}, tags...), labels...) {
+		if containsFold(f.skipTags, tag) {
+			return "--skip-tags " + tag
+		}
+	}
+	return ""
+}
+
+// excludedInclude matches the templates a task was included through against
+// --exclude-include, by reference or file name.
+func (f taskFilter) excludedInclude(includedFrom []string) string {
+	for _, ref := range includedFrom {
+		for _, exclude := range f.excludeIncludes {
+			if ref == exclude || path.Base(ref) == exclude || strings.HasSuffix(ref, "/"+exclude) || strings.HasSuffix(ref, ":"+exclude) {
+				return "--exclude-include " + exclude
+			}
+		}
+	}
+	return ""
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
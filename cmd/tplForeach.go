@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Foreach expands a template task once per item. It is written as the name of
+// a list variable, an inline list, or a matrix mapping names to variables or
+// inline lists:
+//
+//	foreach: hosts
+//	foreach: [web, api]
+//	foreach:
+//	  host: hosts
+//	  region: [us, eu]
+//
+// Each copy sees the current item as {{.item}}, or {{.item.host}} for a matrix.
+type Foreach struct {
+	Var    string
+	Items  []interface{}
+	Matrix yaml.MapSlice
+}
+
+func (f *Foreach) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*f = Foreach{}
+	if err := unmarshal(&f.Var); err == nil {
+		return nil
+	}
+	if err := unmarshal(&f.Items); err == nil {
+		return nil
+	}
+	if err := unmarshal(&f.Matrix); err == nil {
+		return nil
+	}
+	return fmt.Errorf("foreach must be a variable name, a list or a map of lists")
+}
+
+func (f Foreach) MarshalYAML() (interface{}, error) {
+	switch {
+	case f.Var != "":
+		return f.Var, nil
+	case len(f.Matrix) > 0:
+		return f.Matrix, nil
+	}
+	return f.Items, nil
+}
+
+// items returns the values the task is expanded over.
+func (f *Foreach) items(vars map[string]interface{}) ([]interface{}, error) {
+	switch {
+	case f.Var != "":
+		return listVar(vars, f.Var)
+	case len(f.Matrix) > 0:
+		combinations := []map[string]interface{}{{}}
+		for _, dimension := range f.Matrix {
+			name := fmt.Sprint(dimension.Key)
+
+			var values []interface{}
+			switch v := dimension.Value.(type) {
+			case string:
+				list, err := listVar(vars, v)
+				if err != nil {
+					return nil, err
+				}
+				values = list
+			case []interface{}:
+				values = v
+			default:
+				return nil, fmt.Errorf("foreach %s must be a variable name or a list", name)
+			}
+
+			var next []map[string]interface{}
+			for _, combination := range combinations {
+				for _, value := range values {
+					item := map[string]interface{}{}
+					for k, v := range combination {
+						item[k] = v
+					}
+					item[name] = value
+					next = append(next, item)
+				}
+			}
+			combinations = next
+		}
+
+		items := make([]interface{}, len(combinations))
+		for i, combination := range combinations {
+			items[i] = combination
+		}
+		return items, nil
+	}
+	return f.Items, nil
+}
+
+// listVar reads a list variable. Strings, as set with --var, are split on commas.
+func listVar(vars map[string]interface{}, name string) ([]interface{}, error) {
+	value := lookupVar(vars, name)
+	switch v := value.(type) {
+	case nil:
+		return nil, fmt.Errorf("foreach variable %q is not set", name)
+	case []interface{}:
+		return v, nil
+	case string:
+		var items []interface{}
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				items = append(items, s)
+			}
+		}
+		return items, nil
+	}
+	return []interface{}{value}, nil
+}
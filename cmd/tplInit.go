@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// tplInitCmd represents the tpl init command
+var tplInitCmd = &cobra.Command{
+	Use:   "init NAME [FILE]",
+	Short: "Copy a builtin template into your template path",
+	Long: `Copy a builtin starter template into the first template search path, or to
+FILE, so it can be adapted. Builtin templates can also be used directly:
+
+  gojitzu tpl -t builtin:release-checklist`,
+	Args: cobra.RangeArgs(1, 2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		return builtinTemplateNames(), cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		force, _ := cmd.Flags().GetBool("force")
+
+		templatePath := builtinTemplatePath(args[0])
+		data, err := builtinRoot().readFile(templatePath)
+		if err != nil {
+			log.Fatalf("Unknown builtin template %q, expected one of %v", args[0], builtinTemplateNames())
+		}
+
+		var target string
+		if len(args) > 1 {
+			target = args[1]
+		} else {
+			paths := templateSearchPaths()
+			if len(paths) == 0 {
+				log.Fatalf("No template path set, pass a FILE")
+			}
+			target = filepath.Join(paths[0], templatePath)
+		}
+
+		if _, err := os.Stat(target); err == nil && !force {
+			log.Fatalf("%s already exists, use --force to replace it", target)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			log.Fatalf("%v", err)
+		}
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			log.Fatalf("%v", err)
+		}
+		fmt.Printf("Created %s\n", target)
+	},
+}
+
+func init() {
+	tplCmd.AddCommand(tplInitCmd)
+	tplInitCmd.Flags().BoolP("force", "f", false, "replace an existing file")
+}
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// prompter reads answers to questions line by line.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func newPrompter(in io.Reader, out io.Writer) *prompter {
+	return &prompter{in: bufio.NewReader(in), out: out}
+}
+
+// ask prints a question and returns the answer, or def when it is empty. ok
+// is false once the input is closed.
+func (p *prompter) ask(question string, def string) (answer string, ok bool) {
+	if def != "" {
+		question = fmt.Sprintf("%s [%s]", question, def)
+	}
+	answer, ok = p.line(question + ": ")
+	if answer == "" {
+		return def, ok
+	}
+	return answer, ok
+}
+
+// line prints a prompt and reads a line.
+func (p *prompter) line(prompt string) (string, bool) {
+	fmt.Fprint(p.out, prompt)
+	line, err := p.in.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintln(p.out)
+		return "", false
+	}
+	return strings.TrimSpace(line), true
+}
+
+// promptVars asks for the value of each template variable not set with --var,
+// offering the template's value as the default.
+func (p *prompter) promptVars(vars map[string]interface{}, fixed map[string]interface{}) {
+	var names []string
+	for name := range vars {
+		if _, ok := fixed[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		def := valueString(vars[name])
+		if list, ok := vars[name].([]interface{}); ok {
+			var items []string
+			for _, item := range list {
+				items = append(items, valueString(item))
+			}
+			def = strings.Join(items, ",")
+		}
+
+		answer, ok := p.ask(name, def)
+		if !ok {
+			return
+		}
+		if answer != def {
+			vars[name] = answer
+		}
+	}
+}
+
+type pickerItem struct {
+	task     int
+	subTask  int // -1 for the task itself
+	selected bool
+}
+
+// pickTasks lets the tasks and sub-tasks to create be toggled and renamed. It
+// returns false when the run is aborted.
+func (p *prompter) pickTasks(tasks []Task) ([]Task, bool) {
+	var items []*pickerItem
+	for i, task := range tasks {
+		items = append(items, &pickerItem{task: i, subTask: -1, selected: true})
+		for j := range task.SubTasks {
+			items = append(items, &pickerItem{task: i, subTask: j, selected: true})
+		}
+	}
+
+	title := func(item *pickerItem) *string {
+		if item.subTask < 0 {
+			return &tasks[item.task].Title
+		}
+		return &tasks[item.task].SubTasks[item.subTask].Title
+	}
+	toggle := func(n int) {
+		item := items[n]
+		item.selected = !item.selected
+		for _, other := range items {
+			if other.task != item.task {
+				continue
+			}
+			// sub-tasks go with their task, and need it to be created
+			if item.subTask < 0 && other.subTask >= 0 {
+				other.selected = item.selected
+			}
+			if item.subTask >= 0 && item.selected && other.subTask < 0 {
+				other.selected = true
+			}
+		}
+	}
+
+	for {
+		for n, item := range items {
+			check := " "
+			if item.selected {
+				check = "x"
+			}
+			name := *title(item)
+			if item.subTask >= 0 {
+				name = "  - " + name
+			}
+			fmt.Fprintf(p.out, "%3d [%s] %s\n", n+1, check, name)
+		}
+		fmt.Fprintln(p.out, "Toggle with numbers or ranges (3 5-7), a: all, n: none, e N: edit title, enter: done, q: quit")
+
+		answer, ok := p.line("> ")
+		if !ok {
+			return nil, false
+		}
+
+		switch {
+		case answer == "":
+			var picked []Task
+			for _, item := range items {
+				if !item.selected {
+					continue
+				}
+				if item.subTask < 0 {
+					task := tasks[item.task]
+					task.SubTasks = nil
+					picked = append(picked, task)
+					continue
+				}
+				last := &picked[len(picked)-1]
+				last.SubTasks = append(last.SubTasks, tasks[item.task].SubTasks[item.subTask])
+			}
+			return dropUnpickedLinks(tasks, picked), true
+		case answer == "q":
+			return nil, false
+		case answer == "a" || answer == "n":
+			for _, item := range items {
+				item.selected = answer == "a"
+			}
+		case strings.HasPrefix(answer, "e "):
+			n, err := strconv.Atoi(strings.TrimSpace(answer[2:]))
+			if err != nil || n < 1 || n > len(items) {
+				fmt.Fprintf(p.out, "No item %q\n", strings.TrimSpace(answer[2:]))
+				continue
+			}
+			t := title(items[n-1])
+			if answer, ok := p.ask("Title", *t); ok {
+				*t = answer
+			}
+		default:
+			numbers, err := parseSelection(answer, len(items))
+			if err != nil {
+				fmt.Fprintln(p.out, err)
+				continue
+			}
+			for _, n := range numbers {
+				toggle(n - 1)
+			}
+		}
+	}
+}
+
+// dropUnpickedLinks removes links to tasks that were not picked.
+func dropUnpickedLinks(all []Task, picked []Task) []Task {
+	dropped := map[string]bool{}
+	for _, task := range all {
+		dropped[task.ID] = task.ID != ""
+		for _, subTask := range task.SubTasks {
+			dropped[subTask.ID] = subTask.ID != ""
+		}
+	}
+	for _, task := range picked {
+		delete(dropped, task.ID)
+		for _, subTask := range task.SubTasks {
+			delete(dropped, subTask.ID)
+		}
+	}
+
+	keep := func(links []TaskLink) []TaskLink {
+		var kept []TaskLink
+		for _, link := range links {
+			if !dropped[link.Target] {
+				kept = append(kept, link)
+			}
+		}
+		return kept
+	}
+	for i := range picked {
+		picked[i].Links = keep(picked[i].Links)
+		subTasks := make([]SubTask, len(picked[i].SubTasks))
+		for j, subTask := range picked[i].SubTasks {
+			subTask.Links = keep(subTask.Links)
+			subTasks[j] = subTask
+		}
+		picked[i].SubTasks = subTasks
+	}
+	return picked
+}
+
+// parseSelection parses space or comma separated numbers and ranges, EG: 1 3-5.
+func parseSelection(selection string, max int) ([]int, error) {
+	var numbers []int
+	for _, field := range strings.FieldsFunc(selection, func(r rune) bool { return r == ' ' || r == ',' }) {
+		from, to, isRange := strings.Cut(field, "-")
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection %q", field)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(to); err != nil {
+				return nil, fmt.Errorf("invalid selection %q", field)
+			}
+		}
+		if start < 1 || end > max || start > end {
+			return nil, fmt.Errorf("selection %q is not between 1 and %d", field, max)
+		}
+		for n := start; n <= end; n++ {
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers, nil
+}
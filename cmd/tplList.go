@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// tplListCmd represents the tpl list command
+var tplListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available templates",
+	Long: `List the templates on the template search paths and in fetched template
+sources with their task counts, tags and description. Templates shadowed by
+one with the same name earlier in the search paths are listed by their full
+path.
+
+Templates can describe themselves with metadata:
+
+  name: Release checklist
+  description: Tasks for shipping a release
+  owner: release-team@example.com
+  tags: [release, engineering]`,
+	Run: func(cmd *cobra.Command, args []string) {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tREF\tTASKS\tTAGS\tDESCRIPTION")
+		for _, entry := range discoverTemplates() {
+			var template Template
+			if err := template.load(templateRoot{}, entry.ref()); err != nil {
+				fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", entry.ref(), err)
+				continue
+			}
+
+			name := template.Name
+			if name == "" {
+				name = "-"
+			}
+			description := template.Description
+			if entry.shadowedBy != "" {
+				description = strings.TrimSpace(fmt.Sprintf("(shadowed by %s) %s", entry.shadowedBy, description))
+			}
+
+			subTasks := 0
+			for _, task := range template.Tasks {
+				subTasks += len(task.SubTasks)
+			}
+			tasks := fmt.Sprint(len(template.Tasks))
+			if subTasks > 0 {
+				tasks = fmt.Sprintf("%d+%d", len(template.Tasks), subTasks)
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", name, entry.ref(), tasks, strings.Join(template.Tags, ","), description)
+		}
+		w.Flush()
+	},
+}
+
+// completeTemplates completes template references for --templates and tpl show.
+func completeTemplates(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var templates []string
+	for _, entry := range discoverTemplates() {
+		if entry.shadowedBy != "" {
+			templates = append(templates, fmt.Sprintf("%s\tshadowed by %s", entry.ref(), entry.shadowedBy))
+			continue
+		}
+		templates = append(templates, entry.ref())
+	}
+	return templates, cobra.ShellCompDirectiveDefault
+}
+
+func init() {
+	tplCmd.AddCommand(tplListCmd)
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// tplMigrateCmd represents the tpl migrate command
+var tplMigrateCmd = &cobra.Command{
+	Use:   "migrate FILE...",
+	Short: "Rewrite templates in the latest schema version",
+	Long: `Rewrite templates in the latest schema version.
+
+Older templates keep working, they are migrated each time they are loaded.
+migrate prints the migrated template, or with --write replaces the file.
+Comments are not kept.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		write, _ := cmd.Flags().GetBool("write")
+		if len(args) > 1 && !write {
+			log.Fatalf("Use --write to migrate more than one template")
+		}
+
+		for _, file := range args {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+
+			doc, warnings, err := migrateTemplate(data)
+			if err != nil {
+				log.Fatalf("%s: %v", file, err)
+			}
+			for _, warning := range warnings {
+				log.Printf("%s: %s", file, warning)
+			}
+
+			out, err := yaml.Marshal(doc)
+			if err != nil {
+				log.Fatalf("%s: %v", file, err)
+			}
+
+			if !write {
+				os.Stdout.Write(out)
+				continue
+			}
+			if err := os.WriteFile(file, out, 0644); err != nil {
+				log.Fatalf("%v", err)
+			}
+			fmt.Printf("Migrated %s\n", file)
+		}
+	},
+}
+
+func init() {
+	tplCmd.AddCommand(tplMigrateCmd)
+	tplMigrateCmd.Flags().BoolP("write", "w", false, "replace the files instead of printing the result")
+}
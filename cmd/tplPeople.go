@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+// checkTemplatePeople resolves every user named in the tasks so unknown users
+// are reported before any issue is created.
+func checkTemplatePeople(resolver *userResolver, tasks []Task) error {
+	check := func(title string, identifiers ...string) error {
+		for _, identifier := range identifiers {
+			if identifier == "" {
+				continue
+			}
+			if _, err := resolver.resolve(identifier); err != nil {
+				return fmt.Errorf("%s: %v", title, err)
+			}
+		}
+		return nil
+	}
+
+	for _, task := range tasks {
+		if err := check(task.Title, append([]string{task.Assignee, task.Reporter}, task.Watchers...)...); err != nil {
+			return err
+		}
+		for _, subTask := range task.SubTasks {
+			if err := check(subTask.Title, append([]string{subTask.Assignee, subTask.Reporter}, subTask.Watchers...)...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setPeople sets the assignee and reporter of a new issue.
+func setPeople(resolver *userResolver, fields *jira.IssueFields, assignee string, reporter string) {
+	if assignee != "" {
+		user, err := resolver.resolve(assignee)
+		if err != nil {
+			log.Fatalf("Unable to resolve assignee %q: %v", assignee, err)
+		}
+		fields.Assignee = resolver.ref(user)
+	}
+	if reporter != "" {
+		user, err := resolver.resolve(reporter)
+		if err != nil {
+			log.Fatalf("Unable to resolve reporter %q: %v", reporter, err)
+		}
+		fields.Reporter = resolver.ref(user)
+	}
+}
+
+// addWatchers adds the watchers of a new issue.
+func addWatchers(jiraClient *jira.Client, resolver *userResolver, issueKey string, watchers []string) {
+	for _, watcher := range watchers {
+		user, err := resolver.resolve(watcher)
+		if err != nil {
+			log.Fatalf("Unable to resolve watcher %q: %v", watcher, err)
+		}
+		resp, err := jiraClient.Issue.AddWatcher(issueKey, resolver.id(user))
+		checkJiraError(resp, err)
+	}
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+)
+
+// skippedTask records a template entry left out of a run.
+type skippedTask struct {
+	Title  string
+	Parent string
+	Reason string
+}
+
+// expandTasks prepares template tasks for a run. Tasks with foreach: are
+// copied once per item, entries whose when: expression is false are dropped,
+// and the remaining text fields are rendered against the run variables.
+func expandTasks(tasks []Task, vars map[string]interface{}) ([]Task, []skippedTask, error) {
+	var expanded []Task
+	var skipped []skippedTask
+
+	for _, task := range tasks {
+		items := []interface{}{nil}
+		if task.Foreach != nil {
+			var err error
+			if items, err = task.Foreach.items(vars); err != nil {
+				return nil, nil, fmt.Errorf("%s: %v", task.Title, err)
+			}
+		}
+
+		for _, item := range items {
+			taskVars := vars
+			if task.Foreach != nil {
+				taskVars = map[string]interface{}{"item": item}
+				mergeVars(taskVars, vars)
+			}
+
+			ok, err := checkCondition(task.When, taskVars)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: when: %v", task.Title, err)
+			}
+			if !ok {
+				skipped = append(skipped, skippedTask{Title: displayTitle(task.Title, taskVars), Reason: "when: " + task.When})
+				continue
+			}
+
+			rendered, err := renderTask(task, taskVars)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %v", task.Title, err)
+			}
+
+			var subTasks []SubTask
+			for _, subTask := range task.SubTasks {
+				ok, err := checkCondition(subTask.When, taskVars)
+				if err != nil {
+					return nil, nil, fmt.Errorf("%s: when: %v", subTask.Title, err)
+				}
+				if !ok {
+					skipped = append(skipped, skippedTask{Title: displayTitle(subTask.Title, taskVars), Parent: rendered.Title, Reason: "when: " + subTask.When})
+					continue
+				}
+
+				renderedSubTask, err := renderSubTask(subTask, taskVars)
+				if err != nil {
+					return nil, nil, fmt.Errorf("%s: %v", subTask.Title, err)
+				}
+				subTasks = append(subTasks, renderedSubTask)
+			}
+			rendered.SubTasks = subTasks
+			rendered.Foreach = nil
+			expanded = append(expanded, rendered)
+		}
+	}
+
+	return expanded, skipped, nil
+}
+
+// displayTitle renders a title for output, falling back to the raw title.
+func displayTitle(title string, vars map[string]interface{}) string {
+	if rendered, err := expandString(title, vars); err == nil {
+		return rendered
+	}
+	return title
+}
+
+func checkCondition(when string, vars map[string]interface{}) (bool, error) {
+	if when == "" {
+		return true, nil
+	}
+	return evalCondition(when, vars)
+}
+
+// renderStrings renders each of the fields in place.
+func renderStrings(vars map[string]interface{}, fields ...*string) error {
+	for _, field := range fields {
+		rendered, err := expandString(*field, vars)
+		if err != nil {
+			return err
+		}
+		*field = rendered
+	}
+	return nil
+}
+
+// renderList renders a copy of a list so expanded copies do not share it.
+func renderList(vars map[string]interface{}, list []string) ([]string, error) {
+	if list == nil {
+		return nil, nil
+	}
+	rendered := make([]string, len(list))
+	for i, s := range list {
+		var err error
+		if rendered[i], err = expandString(s, vars); err != nil {
+			return nil, err
+		}
+	}
+	return rendered, nil
+}
+
+// renderFields renders the string values of custom fields into a new map.
+func renderFields(vars map[string]interface{}, fields map[string]interface{}) (map[string]interface{}, error) {
+	if fields == nil {
+		return nil, nil
+	}
+	rendered := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		if s, ok := value.(string); ok {
+			var err error
+			if value, err = expandString(s, vars); err != nil {
+				return nil, err
+			}
+		}
+		rendered[name] = value
+	}
+	return rendered, nil
+}
+
+func renderLinks(vars map[string]interface{}, links []TaskLink) ([]TaskLink, error) {
+	if links == nil {
+		return nil, nil
+	}
+	rendered := make([]TaskLink, len(links))
+	for i, link := range links {
+		rendered[i] = link
+		if err := renderStrings(vars, &rendered[i].Type, &rendered[i].Target); err != nil {
+			return nil, err
+		}
+	}
+	return rendered, nil
+}
+
+func renderTask(task Task, vars map[string]interface{}) (Task, error) {
+	err := renderStrings(vars, &task.ID, &task.Type, &task.Title, &task.Description, &task.Assignee, &task.Reporter, &task.Due, &task.Start)
+	if err != nil {
+		return task, err
+	}
+	if task.CustomFields, err = renderFields(vars, task.CustomFields); err != nil {
+		return task, err
+	}
+	if task.Links, err = renderLinks(vars, task.Links); err != nil {
+		return task, err
+	}
+	for _, list := range []*[]string{&task.Labels, &task.Tags, &task.Watchers, &task.Attachments} {
+		if *list, err = renderList(vars, *list); err != nil {
+			return task, err
+		}
+	}
+	return task, nil
+}
+
+func renderSubTask(subTask SubTask, vars map[string]interface{}) (SubTask, error) {
+	err := renderStrings(vars, &subTask.ID, &subTask.Type, &subTask.Title, &subTask.Description, &subTask.Assignee, &subTask.Reporter, &subTask.Due, &subTask.Start)
+	if err != nil {
+		return subTask, err
+	}
+	if subTask.CustomFields, err = renderFields(vars, subTask.CustomFields); err != nil {
+		return subTask, err
+	}
+	if subTask.Links, err = renderLinks(vars, subTask.Links); err != nil {
+		return subTask, err
+	}
+	for _, list := range []*[]string{&subTask.Labels, &subTask.Tags, &subTask.Watchers, &subTask.Attachments} {
+		if *list, err = renderList(vars, *list); err != nil {
+			return subTask, err
+		}
+	}
+	return subTask, nil
+}
+
+// printPlan prints the tasks a run creates, followed by the skipped ones.
+func printPlan(tasks []Task, skipped []skippedTask) {
+	for _, task := range tasks {
+		fmt.Println(task.Title)
+		for _, subTask := range task.SubTasks {
+			fmt.Printf("  - %s\n", subTask.Title)
+		}
+	}
+
+	if len(skipped) > 0 {
+		fmt.Println("Skipped:")
+		for _, s := range skipped {
+			title := s.Title
+			if s.Parent != "" {
+				title = fmt.Sprintf("%s > %s", s.Parent, s.Title)
+			}
+			fmt.Printf("  %s (%s)\n", title, s.Reason)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+// createMetaField is a field of an issue type's create screen.
+type createMetaField struct {
+	FieldID         string `json:"fieldId"`
+	Name            string `json:"name"`
+	Required        bool   `json:"required"`
+	HasDefaultValue bool   `json:"hasDefaultValue"`
+	Schema          struct {
+		Type   string `json:"type"`
+		Custom string `json:"custom"`
+	} `json:"schema"`
+	AllowedValues []map[string]interface{} `json:"allowedValues"`
+}
+
+type createMetaType struct {
+	ID      string                     `json:"id"`
+	Name    string                     `json:"name"`
+	Subtask bool                       `json:"subtask"`
+	Fields  map[string]createMetaField `json:"fields,omitempty"`
+}
+
+// preflight checks every issue of a run against the project's create
+// metadata and returns all the problems Jira would reject them for. epic is
+// nil when the tasks go into an existing epic.
+func preflight(jiraClient *jira.Client, b *tplIssueBuilder, epic *jira.Issue, epicKey string, tasks []Task) ([]string, error) {
+	types, err := getCreateMeta(jiraClient, b.projectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	check := func(what string, issue jira.Issue) {
+		problems = append(problems, checkCreateMeta(types, b.projectKey, what, issue)...)
+	}
+
+	if epic != nil {
+		check(fmt.Sprintf("epic %q", epic.Fields.Summary), *epic)
+		// the tasks are checked against a placeholder for the new epic
+		epicKey = b.projectKey + "-0"
+	}
+	parent := &jira.Issue{Key: b.projectKey + "-0"}
+	for _, task := range tasks {
+		check(fmt.Sprintf("task %q", task.Title), b.task(task, epicKey))
+		for _, subTask := range task.SubTasks {
+			check(fmt.Sprintf("sub-task %q", subTask.Title), b.subTask(subTask, parent))
+		}
+	}
+	return problems, nil
+}
+
+// checkCreateMeta checks an issue's type, required fields, fields missing from
+// the create screen, allowed values and text lengths.
+func checkCreateMeta(types map[string]*createMetaType, projectKey string, what string, issue jira.Issue) []string {
+	var problems []string
+
+	issueType, ok := types[strings.ToLower(issue.Fields.Type.Name)]
+	for _, t := range types {
+		if issue.Fields.Type.ID != "" && t.ID == issue.Fields.Type.ID {
+			issueType, ok = t, true
+		}
+	}
+	if !ok {
+		var names []string
+		for _, t := range types {
+			names = append(names, t.Name)
+		}
+		sort.Strings(names)
+		typeName := issue.Fields.Type.Name
+		if typeName == "" {
+			typeName = issue.Fields.Type.ID
+		}
+		return []string{fmt.Sprintf("%s: issue type %q is not available in %s, expected one of %s", what, typeName, projectKey, strings.Join(names, ", "))}
+	}
+
+	data, err := json.Marshal(issue.Fields)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %v", what, err)}
+	}
+	provided := map[string]interface{}{}
+	json.Unmarshal(data, &provided)
+
+	var ids []string
+	for id := range issueType.Fields {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		field := issueType.Fields[id]
+		if _, ok := provided[id]; !ok && field.Required && !field.HasDefaultValue {
+			problems = append(problems, fmt.Sprintf("%s: required field %q is not set", what, field.Name))
+		}
+	}
+
+	var keys []string
+	for key := range provided {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := provided[key]
+		switch key {
+		case "project", "issuetype", "parent":
+			continue
+		}
+
+		field, ok := issueType.Fields[key]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: field %q is not on the create screen of %s", what, key, issueType.Name))
+			continue
+		}
+
+		if len(field.AllowedValues) > 0 {
+			allowed := map[string]bool{}
+			var allowedNames []string
+			for _, option := range field.AllowedValues {
+				for _, k := range []string{"id", "name", "value", "key"} {
+					if v, ok := option[k]; ok {
+						allowed[strings.ToLower(fmt.Sprint(v))] = true
+					}
+				}
+				for _, k := range []string{"name", "value"} {
+					if v, ok := option[k]; ok {
+						allowedNames = append(allowedNames, fmt.Sprint(v))
+						break
+					}
+				}
+			}
+			for _, v := range fieldValues(value) {
+				if !allowed[strings.ToLower(v)] {
+					problems = append(problems, fmt.Sprintf("%s: %q is not an allowed value of %q, expected one of %s", what, v, field.Name, strings.Join(allowedNames, ", ")))
+				}
+			}
+		}
+
+		if s, ok := value.(string); ok {
+			max := 32767
+			if key == "summary" || strings.HasSuffix(field.Schema.Custom, ":textfield") {
+				max = 255
+			}
+			if len([]rune(s)) > max {
+				problems = append(problems, fmt.Sprintf("%s: %q is %d characters long, the limit is %d", what, field.Name, len([]rune(s)), max))
+			}
+		}
+	}
+
+	return problems
+}
+
+// fieldValues returns the identifiers of a field value, EG: the names of
+// components or the value of a select option.
+func fieldValues(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case map[string]interface{}:
+		for _, k := range []string{"id", "name", "value", "key"} {
+			if val, ok := v[k]; ok {
+				return []string{fmt.Sprint(val)}
+			}
+		}
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			values = append(values, fieldValues(item)...)
+		}
+		return values
+	}
+	return nil
+}
+
+// getCreateMeta returns the issue types of a project and their create fields,
+// keyed by lower case name.
+func getCreateMeta(jiraClient *jira.Client, projectKey string) (map[string]*createMetaType, error) {
+	var types map[string]*createMetaType
+	err := cached("createmeta", projectKey, &types, func() error {
+		var err error
+		types, err = fetchCreateMeta(jiraClient, projectKey)
+		return err
+	})
+	return types, err
+}
+
+// fetchCreateMeta uses the paged createmeta endpoints and falls back to the
+// expanded createmeta endpoint older servers have.
+func fetchCreateMeta(jiraClient *jira.Client, projectKey string) (map[string]*createMetaType, error) {
+	types := map[string]*createMetaType{}
+
+	base := fmt.Sprintf("rest/api/2/issue/createmeta/%s/issuetypes", url.PathEscape(projectKey))
+	typeValues, status, err := getCreateMetaPages(jiraClient, base, "issueTypes")
+	if status == http.StatusNotFound {
+		return getLegacyCreateMeta(jiraClient, projectKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, raw := range typeValues {
+		var issueType createMetaType
+		if err := json.Unmarshal(raw, &issueType); err != nil {
+			return nil, err
+		}
+
+		fieldValues, _, err := getCreateMetaPages(jiraClient, base+"/"+url.PathEscape(issueType.ID), "fields")
+		if err != nil {
+			return nil, err
+		}
+		issueType.Fields = map[string]createMetaField{}
+		for _, raw := range fieldValues {
+			var field createMetaField
+			if err := json.Unmarshal(raw, &field); err != nil {
+				return nil, err
+			}
+			issueType.Fields[field.FieldID] = field
+		}
+		types[strings.ToLower(issueType.Name)] = &issueType
+	}
+	return types, nil
+}
+
+// getCreateMetaPages reads every page of a createmeta endpoint. Jira Cloud
+// returns the items under listKey, Data Center under values.
+func getCreateMetaPages(jiraClient *jira.Client, path string, listKey string) ([]json.RawMessage, int, error) {
+	var items []json.RawMessage
+	for {
+		req, err := jiraClient.NewRequest("GET", fmt.Sprintf("%s?startAt=%d&maxResults=100", path, len(items)), nil)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		page := map[string]json.RawMessage{}
+		resp, err := jiraClient.Do(req, &page)
+		if err != nil {
+			status := 0
+			if resp != nil {
+				status = resp.StatusCode
+			}
+			return nil, status, err
+		}
+
+		var pageItems []json.RawMessage
+		if raw, ok := page[listKey]; ok {
+			json.Unmarshal(raw, &pageItems)
+		} else {
+			json.Unmarshal(page["values"], &pageItems)
+		}
+		items = append(items, pageItems...)
+
+		var total int
+		var isLast bool
+		_, hasTotal := page["total"]
+		_, hasIsLast := page["isLast"]
+		json.Unmarshal(page["total"], &total)
+		json.Unmarshal(page["isLast"], &isLast)
+		if len(pageItems) == 0 || isLast || (hasTotal && len(items) >= total) || (!hasTotal && !hasIsLast) {
+			return items, resp.StatusCode, nil
+		}
+	}
+}
+
+func getLegacyCreateMeta(jiraClient *jira.Client, projectKey string) (map[string]*createMetaType, error) {
+	req, err := jiraClient.NewRequest("GET", "rest/api/2/issue/createmeta?expand=projects.issuetypes.fields&projectKeys="+url.QueryEscape(projectKey), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := struct {
+		Projects []struct {
+			IssueTypes []struct {
+				createMetaType
+				Fields map[string]createMetaField `json:"fields"`
+			} `json:"issuetypes"`
+		} `json:"projects"`
+	}{}
+	if _, err := jiraClient.Do(req, &meta); err != nil {
+		return nil, err
+	}
+	if len(meta.Projects) == 0 {
+		return nil, fmt.Errorf("no create metadata for %s", projectKey)
+	}
+
+	types := map[string]*createMetaType{}
+	for _, t := range meta.Projects[0].IssueTypes {
+		issueType := t.createMetaType
+		issueType.Fields = t.Fields
+		types[strings.ToLower(issueType.Name)] = &issueType
+	}
+	return types, nil
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// latestTemplateVersion is the template schema the Template types describe.
+// Older templates are migrated to it when they are loaded.
+const latestTemplateVersion = "2"
+
+// templateMigrations upgrade a template document from the version they are
+// keyed by to the following one.
+var templateMigrations = map[string]func(doc yaml.MapSlice) yaml.MapSlice{
+	// version 2 added includes and subtasks, both optional
+	"1": func(doc yaml.MapSlice) yaml.MapSlice { return doc },
+}
+
+// deprecatedTaskKeys are task and sub-task keys older templates used, with
+// the key that replaced them. They are still accepted and reported.
+var deprecatedTaskKeys = map[string]string{
+	"summary":   "title",
+	"sub_tasks": "subtasks",
+}
+
+// migrateTemplate upgrades a template document to the latest version. It
+// returns warnings for deprecated keys and an error for versions it does not
+// know. Templates without a version are version 1.
+func migrateTemplate(data []byte) (yaml.MapSlice, []string, error) {
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+
+	version := "1"
+	if v, ok := mapValue(doc, "version"); ok {
+		version = fmt.Sprint(v)
+	}
+
+	for version != latestTemplateVersion {
+		migrate, ok := templateMigrations[version]
+		if !ok {
+			return nil, nil, fmt.Errorf("unsupported template version %q, expected 1 to %s", version, latestTemplateVersion)
+		}
+		doc = migrate(doc)
+		version = nextTemplateVersion(version)
+	}
+
+	var warnings []string
+	for _, task := range templateTaskMaps(doc) {
+		warnings = append(warnings, renameDeprecatedKeys(task, taskKeys)...)
+		if subTasks, ok := mapValue(task, "subtasks"); ok {
+			if list, ok := subTasks.([]interface{}); ok {
+				for _, subTask := range list {
+					if subTask, ok := subTask.(yaml.MapSlice); ok {
+						warnings = append(warnings, renameDeprecatedKeys(subTask, subTaskKeys)...)
+					}
+				}
+			}
+		}
+	}
+
+	return setMapValue(doc, "version", latestTemplateVersion), warnings, nil
+}
+
+var (
+	taskKeys    = yamlKeys(reflect.TypeOf(Task{}))
+	subTaskKeys = yamlKeys(reflect.TypeOf(SubTask{}))
+)
+
+// yamlKeys returns the keys a struct is decoded from.
+func yamlKeys(t reflect.Type) map[string]bool {
+	keys := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ","); name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+// templateTaskMaps returns the tasks and overrides of a template document.
+func templateTaskMaps(doc yaml.MapSlice) []yaml.MapSlice {
+	var tasks []yaml.MapSlice
+	if list, ok := mapValue(doc, "tasks"); ok {
+		if list, ok := list.([]interface{}); ok {
+			for _, task := range list {
+				if task, ok := task.(yaml.MapSlice); ok {
+					tasks = append(tasks, task)
+				}
+			}
+		}
+	}
+	if overrides, ok := mapValue(doc, "override"); ok {
+		if overrides, ok := overrides.(yaml.MapSlice); ok {
+			for _, item := range overrides {
+				if task, ok := item.Value.(yaml.MapSlice); ok {
+					tasks = append(tasks, task)
+				}
+			}
+		}
+	}
+	return tasks
+}
+
+// renameDeprecatedKeys replaces the deprecated keys of m the current schema
+// does not know, returning a warning for each. A deprecated key is left, and
+// so ignored, when its replacement is set too.
+func renameDeprecatedKeys(m yaml.MapSlice, current map[string]bool) []string {
+	var warnings []string
+	for i := range m {
+		key := fmt.Sprint(m[i].Key)
+		newKey, ok := deprecatedTaskKeys[key]
+		if !ok || current[key] || !current[newKey] {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("%s is deprecated, use %s", key, newKey))
+		if _, set := mapValue(m, newKey); !set {
+			m[i].Key = newKey
+		}
+	}
+	return warnings
+}
+
+func nextTemplateVersion(version string) string {
+	var n int
+	fmt.Sscan(version, &n)
+	return fmt.Sprint(n + 1)
+}
+
+func mapValue(m yaml.MapSlice, key string) (interface{}, bool) {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+// setMapValue sets a key, adding it first when it is missing.
+func setMapValue(m yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for i := range m {
+		if m[i].Key == key {
+			m[i].Value = value
+			return m
+		}
+	}
+	return append(yaml.MapSlice{{Key: key, Value: value}}, m...)
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestMigrateTemplateDeprecatedKeys(t *testing.T) {
+	data := []byte(`version: "1"
+tasks:
+  - summary: Prepare
+    sub_tasks:
+      - summary: Check
+  - title: Ship
+    summary: ignored
+`)
+
+	doc, warnings, err := migrateTemplate(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"summary is deprecated, use title",
+		"sub_tasks is deprecated, use subtasks",
+		"summary is deprecated, use title",
+		"summary is deprecated, use title",
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings are %q, want %q", warnings, want)
+	}
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var template Template
+	if err := yaml.Unmarshal(out, &template); err != nil {
+		t.Fatal(err)
+	}
+	if template.Version != latestTemplateVersion || len(template.Tasks) != 2 {
+		t.Fatalf("unexpected template %+v", template)
+	}
+	if task := template.Tasks[0]; task.Title != "Prepare" || len(task.SubTasks) != 1 || task.SubTasks[0].Title != "Check" {
+		t.Errorf("unexpected migrated task %+v", task)
+	}
+	if template.Tasks[1].Title != "Ship" {
+		t.Errorf("the deprecated key replaced the title of %+v", template.Tasks[1])
+	}
+
+	if _, warnings, _ := migrateTemplate([]byte("version: \"2\"\ntasks:\n  - title: Ship\n")); len(warnings) > 0 {
+		t.Errorf("unexpected warnings %q", warnings)
+	}
+}
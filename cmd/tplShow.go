@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// tplShowCmd represents the tpl show command
+var tplShowCmd = &cobra.Command{
+	Use:   "show NAME",
+	Short: "Show a template and its tasks",
+	Long: `Show a template's metadata and the task tree it creates after includes.
+NAME is a template reference, as used with tpl -t, or the name: of a template.
+
+Tasks are rendered with the template's vars and any --var values. When that
+is not possible, EG: a variable has no value yet, the raw tasks are shown.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTemplates,
+	Run: func(cmd *cobra.Command, args []string) {
+		varPairs, _ := cmd.Flags().GetStringArray("var")
+		vars, err := parseVars(varPairs)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		ref := findTemplateRef(args[0])
+		var template Template
+		if err := template.load(templateRoot{}, ref); err != nil {
+			log.Fatalf("Unable to load template: %v", err)
+		}
+
+		title := template.Name
+		if title == "" {
+			title = ref
+		}
+		fmt.Println(title)
+		fmt.Printf("  Ref:      %s\n", ref)
+		if template.Owner != "" {
+			fmt.Printf("  Owner:    %s\n", template.Owner)
+		}
+		if len(template.Tags) > 0 {
+			fmt.Printf("  Tags:     %s\n", strings.Join(template.Tags, ", "))
+		}
+		if template.Epic != nil && template.Epic.Title != "" {
+			fmt.Printf("  Epic:     %s\n", template.Epic.Title)
+		}
+		if template.Extends != "" {
+			fmt.Printf("  Extends:  %s\n", template.Extends)
+		}
+		if len(template.Includes) > 0 {
+			fmt.Printf("  Includes: %s\n", strings.Join(template.Includes, ", "))
+		}
+		if template.Description != "" {
+			fmt.Println()
+			fmt.Println(indent(template.Description, "  "))
+		}
+		fmt.Println()
+
+		mergeVars(vars, template.Vars)
+		tasks, skipped, err := expandTasks(template.Tasks, vars)
+		if err != nil {
+			fmt.Printf("Unable to render tasks (%v), showing them unrendered:\n\n", err)
+			printPlan(template.Tasks, nil)
+			return
+		}
+		printPlan(tasks, skipped)
+	},
+}
+
+// findTemplateRef returns the reference of the template with the given name:,
+// or name itself when no template declares it.
+func findTemplateRef(name string) string {
+	entries := discoverTemplates()
+	for _, entry := range entries {
+		if entry.ref() == name {
+			return name
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.shadowedBy != "" {
+			continue
+		}
+		var template Template
+		if err := template.load(templateRoot{}, entry.ref()); err != nil {
+			continue
+		}
+		if strings.EqualFold(template.Name, name) {
+			return entry.ref()
+		}
+	}
+	return name
+}
+
+func init() {
+	tplCmd.AddCommand(tplShowCmd)
+	tplShowCmd.Flags().StringArray("var", []string{}, "template variable, EG: --var owner=jdoe@example.com")
+}
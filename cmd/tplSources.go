@@ -0,0 +1,326 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/defektive/gojitzu/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// tplSourcesCmd represents the tpl sources command
+var tplSourcesCmd = &cobra.Command{
+	Use:   "sources",
+	Short: "Manage template sources",
+	Long: `Manage template sources.
+
+Template sources are git repositories or .tar.gz/.zip bundles listed under
+template_sources in your config. They are fetched into the user cache
+directory on first use and their templates are referenced as NAME:path:
+
+  template_sources:
+    - name: team
+      url: git@github.com:example/jira-templates.git
+      ref: v1.2.0
+    - name: runbooks
+      url: https://example.com/runbooks.tar.gz
+
+  gojitzu tpl -t team:engagements/external.yaml`,
+}
+
+var tplSourcesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List template sources",
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, source := range Config.TemplateSources {
+			status := "not fetched"
+			if _, err := os.Stat(templateSourceDir(source)); err == nil {
+				status = templateSourceDir(source)
+			}
+			ref := source.Ref
+			if ref == "" {
+				ref = "-"
+			}
+			fmt.Printf("%-15s %-10s %s (%s)\n", source.Name, ref, source.URL, status)
+		}
+	},
+}
+
+var tplSourcesUpdateCmd = &cobra.Command{
+	Use:   "update [NAME...]",
+	Short: "Fetch the latest version of template sources",
+	Run: func(cmd *cobra.Command, args []string) {
+		names := args
+		if len(names) == 0 {
+			for _, source := range Config.TemplateSources {
+				names = append(names, source.Name)
+			}
+		}
+
+		for _, name := range names {
+			source, ok := findTemplateSource(name)
+			if !ok {
+				log.Fatalf("Unknown template source %q", name)
+			}
+			if err := fetchTemplateSource(source); err != nil {
+				log.Fatalf("Unable to update %s: %v", name, err)
+			}
+			fmt.Printf("Updated %s\n", name)
+		}
+	},
+}
+
+func findTemplateSource(name string) (config.TemplateSource, bool) {
+	for _, source := range Config.TemplateSources {
+		if source.Name == name {
+			return source, true
+		}
+	}
+	return config.TemplateSource{}, false
+}
+
+// templateSourceDir is where a source is cached.
+func templateSourceDir(source config.TemplateSource) string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	return filepath.Join(cacheDir, "gojitzu", "sources", source.Name)
+}
+
+// templateSourceRoot returns the root of a template source, fetching it first
+// if it is not cached yet.
+func templateSourceRoot(name string) (templateRoot, error) {
+	source, ok := findTemplateSource(name)
+	if !ok {
+		return templateRoot{}, fmt.Errorf("unknown template source %q", name)
+	}
+
+	dir := templateSourceDir(source)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := fetchTemplateSource(source); err != nil {
+			return templateRoot{}, err
+		}
+	}
+
+	return dirRoot(name, filepath.Join(archiveRoot(dir), source.Path)), nil
+}
+
+func isArchive(url string) bool {
+	lower := strings.ToLower(url)
+	return strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz") || strings.HasSuffix(lower, ".zip")
+}
+
+// fetchTemplateSource clones or refreshes a source in the cache.
+func fetchTemplateSource(source config.TemplateSource) error {
+	dir := templateSourceDir(source)
+	if isArchive(source.URL) {
+		return fetchArchive(source.URL, dir)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
+		os.RemoveAll(dir)
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return err
+		}
+		if err := runGit("", "clone", "--quiet", source.URL, dir); err != nil {
+			return err
+		}
+		if source.Ref == "" {
+			return nil
+		}
+	}
+
+	ref := source.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if err := runGit(dir, "fetch", "--quiet", "--tags", "origin", ref); err != nil {
+		return err
+	}
+	return runGit(dir, "checkout", "--quiet", "--detach", "FETCH_HEAD")
+}
+
+func runGit(dir string, args ...string) error {
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %v", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// fetchArchive downloads, when url is http(s), and extracts an archive into dir.
+func fetchArchive(url string, dir string) error {
+	archivePath := url
+	if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+		resp, err := http.Get(url)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("downloading %s: %s", url, resp.Status)
+		}
+
+		tmp, err := os.CreateTemp("", "gojitzu-source-*"+filepath.Ext(url))
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		_, err = io.Copy(tmp, resp.Body)
+		tmp.Close()
+		if err != nil {
+			return err
+		}
+		archivePath = tmp.Name()
+	}
+
+	// extract next to the cache dir and swap it in, so a failed update keeps
+	// the previous version
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	staging, err := os.MkdirTemp(filepath.Dir(dir), ".extract-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	if strings.HasSuffix(strings.ToLower(url), ".zip") {
+		err = extractZip(archivePath, staging)
+	} else {
+		err = extractTarGz(archivePath, staging)
+	}
+	if err != nil {
+		return err
+	}
+
+	os.RemoveAll(dir)
+	return os.Rename(staging, dir)
+}
+
+// archiveRoot skips the single top level directory most archives wrap their
+// content in.
+func archiveRoot(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() || entries[0].Name() == ".git" {
+		return dir
+	}
+	return filepath.Join(dir, entries[0].Name())
+}
+
+// extractPath guards against archive entries escaping the destination.
+func extractPath(dest string, name string) (string, error) {
+	target := filepath.Join(dest, name)
+	if target != dest && !strings.HasPrefix(target, filepath.Clean(dest)+string(os.PathSeparator)) {
+		return "", fmt.Errorf("archive entry %q is outside the destination", name)
+	}
+	return target, nil
+}
+
+func writeExtractedFile(target string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func extractTarGz(archivePath string, dest string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := extractPath(dest, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeExtractedFile(target, tr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func extractZip(archivePath string, dest string) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, file := range zr.File {
+		target, err := extractPath(dest, file.Name)
+		if err != nil {
+			return err
+		}
+
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = writeExtractedFile(target, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	tplCmd.AddCommand(tplSourcesCmd)
+	tplSourcesCmd.AddCommand(tplSourcesListCmd, tplSourcesUpdateCmd)
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// parseVars parses key=value pairs given with --var.
+func parseVars(pairs []string) (map[string]interface{}, error) {
+	vars := map[string]interface{}{}
+	for _, kv := range pairs {
+		key, value, found := strings.Cut(kv, "=")
+		if !found || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid variable %q, expected name=value", kv)
+		}
+		vars[strings.TrimSpace(key)] = value
+	}
+	return vars, nil
+}
+
+// mergeVars copies variables from src into dst unless dst already defines them.
+func mergeVars(dst map[string]interface{}, src map[string]interface{}) {
+	for key, value := range src {
+		if _, found := dst[key]; !found {
+			dst[key] = value
+		}
+	}
+}
+
+var actionRe = regexp.MustCompile(`\{\{(.*?)\}\}`)
+
+// controlActions start or continue a block, they only parse as a whole.
+var controlActions = []string{"if", "else", "end", "range", "with", "define", "block", "template", "break", "continue"}
+
+// expandString renders text as a Go template against the run variables,
+// EG: "{{.owner}}". Referencing an undefined variable is an error.
+func expandString(text string, vars map[string]interface{}) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	t, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		// Jira text uses {{...}} for monospace, keep what is not a template action
+		if t, err = template.New("").Option("missingkey=error").Parse(escapeLiteralActions(text)); err != nil {
+			return "", err
+		}
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, vars); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// escapeLiteralActions escapes the {{...}} sequences that do not parse as a
+// template action on their own, EG: Jira monospace like {{kubectl get pods}}.
+func escapeLiteralActions(text string) string {
+	return actionRe.ReplaceAllStringFunc(text, func(action string) string {
+		inner := strings.TrimSpace(strings.Trim(actionRe.FindStringSubmatch(action)[1], "-"))
+		if fields := strings.Fields(inner); len(fields) > 0 {
+			for _, keyword := range controlActions {
+				if fields[0] == keyword {
+					return action
+				}
+			}
+		}
+		if _, err := template.New("").Parse(action); err == nil {
+			return action
+		}
+		return `{{"{{"}}` + action[2:]
+	})
+}
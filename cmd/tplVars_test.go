@@ -0,0 +1,28 @@
+package cmd
+
+import "testing"
+
+func TestExpandStringKeepsJiraMonospace(t *testing.T) {
+	vars := map[string]interface{}{"service": "api", "prod": true}
+	for text, want := range map[string]string{
+		"plain":               "plain",
+		"Deploy {{.service}}": "Deploy api",
+		"Run {{kubectl get pods}} on {{.service}}": "Run {{kubectl get pods}} on api",
+		"Check {{$HOME}} and {{/etc/hosts}}":       "Check {{$HOME}} and {{/etc/hosts}}",
+		"{{if .prod}}Page {{oncall}}{{end}}":       "Page {{oncall}}",
+		`{{"{{"}}escaped}}`:                        "{{escaped}}",
+	} {
+		got, err := expandString(text, vars)
+		if err != nil {
+			t.Errorf("%q: %v", text, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%q rendered as %q, want %q", text, got, want)
+		}
+	}
+
+	if _, err := expandString("{{.missing}}", vars); err == nil {
+		t.Errorf("expected an error for an undefined variable")
+	}
+}
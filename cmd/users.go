@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+var accountIDRe = regexp.MustCompile(`^([0-9a-f]{24}|\d+:[0-9a-f-]+)$`)
+
+// userResolver turns the user identifiers accepted in flags and templates
+// into Jira users. Identifiers can be an email, a username, an account id or
+// one of the symbolic roles:
+//
+//	me                   the authenticated user
+//	lead                 the project lead
+//	component-lead:NAME  the lead of the NAME component
+//	component-lead       the lead of the project's only led component
+//
+// Jira Cloud identifies users by account id while Data Center uses the
+// username, so resolved users only carry the identifier the server expects.
+type userResolver struct {
+	client     *jira.Client
+	projectKey string
+	project    *jira.Project
+	cloud      *bool
+	cache      map[string]*jira.User
+}
+
+func newUserResolver(jiraClient *jira.Client, projectKey string) *userResolver {
+	return &userResolver{
+		client:     jiraClient,
+		projectKey: projectKey,
+		cache:      map[string]*jira.User{},
+	}
+}
+
+// getProject loads the project the roles refer to on first use.
+func (r *userResolver) getProject() *jira.Project {
+	if r.project == nil {
+		r.project = getProject(r.client, r.projectKey)
+	}
+	return r.project
+}
+
+// isCloud reports whether the server is Jira Cloud.
+func (r *userResolver) isCloud() bool {
+	if r.cloud == nil {
+		cloud := isJiraCloud(r.client)
+		r.cloud = &cloud
+	}
+	return *r.cloud
+}
+
+// isJiraCloud asks the server for its deployment type.
+func isJiraCloud(jiraClient *jira.Client) bool {
+	serverInfo := struct {
+		DeploymentType string `json:"deploymentType"`
+	}{}
+	cached("server-info", "", &serverInfo, func() error {
+		req, err := jiraClient.NewRequest("GET", "rest/api/2/serverInfo", nil)
+		if err != nil {
+			panic(err)
+		}
+		resp, err := jiraClient.Do(req, &serverInfo)
+		checkJiraError(resp, err)
+		return nil
+	})
+
+	return strings.EqualFold(serverInfo.DeploymentType, "Cloud")
+}
+
+// resolve finds the user for an identifier.
+func (r *userResolver) resolve(identifier string) (*jira.User, error) {
+	identifier = strings.TrimSpace(identifier)
+	if user, found := r.cache[identifier]; found {
+		return user, nil
+	}
+
+	user, err := r.lookup(identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	r.cache[identifier] = user
+	return user, nil
+}
+
+func (r *userResolver) lookup(identifier string) (*jira.User, error) {
+	role, arg, _ := strings.Cut(identifier, ":")
+	switch strings.ToLower(role) {
+	case "me":
+		user, resp, err := r.client.User.GetSelf()
+		checkJiraError(resp, err)
+		return user, nil
+	case "lead":
+		project := r.getProject()
+		if project.Lead.Name == "" && project.Lead.AccountID == "" {
+			return nil, fmt.Errorf("project %s has no lead", project.Key)
+		}
+		return &project.Lead, nil
+	case "component-lead":
+		return r.componentLead(arg)
+	}
+
+	if r.isCloud() && accountIDRe.MatchString(identifier) {
+		return &jira.User{AccountID: identifier}, nil
+	}
+
+	return r.search(identifier)
+}
+
+func (r *userResolver) componentLead(name string) (*jira.User, error) {
+	project := r.getProject()
+
+	var leads []*jira.User
+	for i, c := range project.Components {
+		hasLead := c.Lead.Name != "" || c.Lead.AccountID != ""
+		if name != "" && strings.EqualFold(c.Name, name) {
+			if !hasLead {
+				return nil, fmt.Errorf("component %q has no lead", name)
+			}
+			return &project.Components[i].Lead, nil
+		}
+		if name == "" && hasLead {
+			leads = append(leads, &project.Components[i].Lead)
+		}
+	}
+
+	if name != "" {
+		return nil, fmt.Errorf("unknown component %q", name)
+	}
+	if len(leads) != 1 {
+		return nil, fmt.Errorf("project %s has %d component leads, use component-lead:NAME", project.Key, len(leads))
+	}
+	return leads[0], nil
+}
+
+// search looks the identifier up with the user search endpoint, which takes
+// "query" on Cloud and "username" on Data Center.
+func (r *userResolver) search(identifier string) (*jira.User, error) {
+	param := "username"
+	if r.isCloud() {
+		param = "query"
+	}
+
+	// identifiers are hashed, the cache key would mix up a@b.c and a_b.c
+	sum := sha256.Sum256([]byte(identifier))
+	cacheID := param + "-" + hex.EncodeToString(sum[:])
+
+	// empty results are not cached, the user may be added any time
+	var users []jira.User
+	cache := openMetaCache()
+	if !cache.get("users", cacheID, &users) {
+		req, err := r.client.NewRequest("GET", fmt.Sprintf("rest/api/2/user/search?%s=%s", param, url.QueryEscape(identifier)), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := r.client.Do(req, &users)
+		checkJiraError(resp, err)
+		if len(users) > 0 {
+			cache.put("users", cacheID, users)
+		}
+	}
+
+	var matches []jira.User
+	for _, u := range users {
+		for _, candidate := range []string{u.EmailAddress, u.Name, u.AccountID, u.Key, u.DisplayName} {
+			if candidate != "" && strings.EqualFold(candidate, identifier) {
+				matches = append(matches, u)
+				break
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		// the search also matches prefixes, a single hit such as bobby.tables
+		// for bob is not who the template meant
+		if len(users) > 0 {
+			var suggestions []string
+			for _, u := range users {
+				for _, candidate := range []string{u.EmailAddress, u.Name, u.AccountID} {
+					if candidate != "" {
+						suggestions = append(suggestions, candidate)
+						break
+					}
+				}
+			}
+			return nil, fmt.Errorf("no exact match for %q (did you mean %s?)", identifier, strings.Join(suggestions, ", "))
+		}
+		return nil, fmt.Errorf("no user found for %q", identifier)
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("%q matches %d users, be more specific", identifier, len(matches))
+	}
+}
+
+// ref returns a user carrying only the identifier the server expects.
+func (r *userResolver) ref(user *jira.User) *jira.User {
+	if r.isCloud() {
+		return &jira.User{AccountID: user.AccountID}
+	}
+	return &jira.User{Name: user.Name}
+}
+
+// id returns the identifier the server expects, as used by the watchers api.
+func (r *userResolver) id(user *jira.User) string {
+	if r.isCloud() {
+		return user.AccountID
+	}
+	return user.Name
+}
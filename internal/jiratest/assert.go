@@ -0,0 +1,134 @@
+package jiratest
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+// Issue returns an issue as the server would return it.
+func (s *Server) Issue(key string) (jira.Issue, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	issue := s.issue(key)
+	if issue == nil {
+		return jira.Issue{}, false
+	}
+	return s.view(issue), true
+}
+
+// Created returns the issues created through the API, in order.
+func (s *Server) Created() []jira.Issue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var issues []jira.Issue
+	for _, key := range s.created {
+		issues = append(issues, s.view(s.issue(key)))
+	}
+	return issues
+}
+
+// Links returns the issue links created through the API.
+func (s *Server) Links() []jira.IssueLink {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]jira.IssueLink(nil), s.links...)
+}
+
+// Watchers returns the watchers added to an issue through the API.
+func (s *Server) Watchers(key string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.watchers[key]...)
+}
+
+// Requests returns the requests the server received.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequireIssue returns an issue, failing the test when it does not exist.
+func (s *Server) RequireIssue(t testing.TB, key string) jira.Issue {
+	t.Helper()
+	issue, ok := s.Issue(key)
+	if !ok {
+		t.Fatalf("issue %s does not exist", key)
+	}
+	return issue
+}
+
+// RequireCreated returns the created issue with a summary, failing the test
+// when there is none.
+func (s *Server) RequireCreated(t testing.TB, summary string) jira.Issue {
+	t.Helper()
+	for _, issue := range s.Created() {
+		if issue.Fields.Summary == summary {
+			return issue
+		}
+	}
+	t.Fatalf("no issue %q was created", summary)
+	return jira.Issue{}
+}
+
+// AssertCreated checks the summaries of the created issues, in order.
+func (s *Server) AssertCreated(t testing.TB, summaries ...string) {
+	t.Helper()
+	var got []string
+	for _, issue := range s.Created() {
+		got = append(got, issue.Fields.Summary)
+	}
+	if strings.Join(got, "\n") != strings.Join(summaries, "\n") {
+		t.Errorf("created issues:\n  got  %q\n  want %q", got, summaries)
+	}
+}
+
+// AssertLinked checks that a link of linkType was created from the inward to
+// the outward issue.
+func (s *Server) AssertLinked(t testing.TB, linkType string, inwardKey string, outwardKey string) {
+	t.Helper()
+	for _, link := range s.Links() {
+		if strings.EqualFold(link.Type.Name, linkType) && link.InwardIssue.Key == inwardKey && link.OutwardIssue.Key == outwardKey {
+			return
+		}
+	}
+	t.Errorf("no %q link from %s to %s, links: %s", linkType, inwardKey, outwardKey, s.describeLinks())
+}
+
+func (s *Server) describeLinks() string {
+	var links []string
+	for _, link := range s.Links() {
+		links = append(links, link.InwardIssue.Key+" "+link.Type.Name+" "+link.OutwardIssue.Key)
+	}
+	return "[" + strings.Join(links, ", ") + "]"
+}
+
+// AssertRequested checks that the server received a request, matching the
+// path without its query.
+func (s *Server) AssertRequested(t testing.TB, method string, path string) {
+	t.Helper()
+	if len(s.RequestsTo(method, path)) == 0 {
+		t.Errorf("no %s %s request", method, path)
+	}
+}
+
+// RequestsTo returns the requests received for a method and path.
+func (s *Server) RequestsTo(method string, path string) []Request {
+	var requests []Request
+	for _, r := range s.Requests() {
+		if r.Method == method && r.Path == path {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+// DecodeBody decodes the JSON body of a request.
+func (r Request) DecodeBody(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
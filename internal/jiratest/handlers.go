@@ -0,0 +1,419 @@
+package jiratest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+func (s *Server) serverInfo(w http.ResponseWriter, r *http.Request) {
+	deploymentType := "Server"
+	if s.Cloud {
+		deploymentType = "Cloud"
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"baseUrl":        s.URL,
+		"version":        "9.12.0",
+		"deploymentType": deploymentType,
+	})
+}
+
+func (s *Server) myself(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.users) == 0 {
+		writeError(w, http.StatusUnauthorized, "no users, add one with AddUser")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.users[0])
+}
+
+func (s *Server) searchUsers(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query().Get("query")
+	if query == "" {
+		query = r.URL.Query().Get("username")
+	}
+	query = strings.ToLower(query)
+
+	users := []jira.User{}
+	for _, u := range s.users {
+		for _, candidate := range []string{u.Name, u.EmailAddress, u.DisplayName, u.AccountID} {
+			if candidate != "" && strings.Contains(strings.ToLower(candidate), query) {
+				users = append(users, u)
+				break
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, users)
+}
+
+func (s *Server) listProjects(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	projects := []jira.Project{}
+	for _, p := range s.projects {
+		projects = append(projects, p.Project)
+	}
+	writeJSON(w, http.StatusOK, projects)
+}
+
+func (s *Server) getProject(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.project(r.PathValue("key"))
+	if p == nil {
+		p = s.projectByID(r.PathValue("key"))
+	}
+	if p == nil {
+		writeError(w, http.StatusNotFound, "No project could be found with key '%s'.", r.PathValue("key"))
+		return
+	}
+
+	// go-jira's Project has no style, add it the way Jira returns it
+	data, _ := json.Marshal(p.Project)
+	body := map[string]interface{}{}
+	json.Unmarshal(data, &body)
+	body["style"] = "classic"
+	body["simplified"] = p.teamManaged
+	if p.teamManaged {
+		body["style"] = "next-gen"
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (s *Server) listFields(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fields := append([]jira.Field{}, systemFields...)
+	writeJSON(w, http.StatusOK, append(fields, s.fields...))
+}
+
+// systemFields are the fields every project has.
+var systemFields = []jira.Field{
+	{ID: "summary", Name: "Summary", Schema: jira.FieldSchema{Type: "string", System: "summary"}},
+	{ID: "issuetype", Name: "Issue Type", Schema: jira.FieldSchema{Type: "issuetype", System: "issuetype"}},
+	{ID: "project", Name: "Project", Schema: jira.FieldSchema{Type: "project", System: "project"}},
+	{ID: "description", Name: "Description", Schema: jira.FieldSchema{Type: "string", System: "description"}},
+	{ID: "assignee", Name: "Assignee", Schema: jira.FieldSchema{Type: "user", System: "assignee"}},
+	{ID: "reporter", Name: "Reporter", Schema: jira.FieldSchema{Type: "user", System: "reporter"}},
+	{ID: "labels", Name: "Labels", Schema: jira.FieldSchema{Type: "array", Items: "string", System: "labels"}},
+	{ID: "components", Name: "Components", Schema: jira.FieldSchema{Type: "array", Items: "component", System: "components"}},
+	{ID: "duedate", Name: "Due date", Schema: jira.FieldSchema{Type: "date", System: "duedate"}},
+	{ID: "parent", Name: "Parent", Schema: jira.FieldSchema{Type: "issuelink", System: "parent"}},
+}
+
+// createMetaTypes lists the issue types of a project in the paged
+// createmeta format of Jira Cloud.
+func (s *Server) createMetaTypes(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.project(r.PathValue("key"))
+	if p == nil {
+		writeError(w, http.StatusNotFound, "No project could be found with key '%s'.", r.PathValue("key"))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"startAt":    0,
+		"maxResults": len(p.IssueTypes),
+		"total":      len(p.IssueTypes),
+		"issueTypes": p.IssueTypes,
+	})
+}
+
+// createMetaFields lists the create screen of an issue type, which has every
+// field of the server. Only summary, issue type and project are required and
+// the parent field is only on sub-tasks or in team-managed projects.
+func (s *Server) createMetaFields(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.project(r.PathValue("key"))
+	if p == nil {
+		writeError(w, http.StatusNotFound, "No project could be found with key '%s'.", r.PathValue("key"))
+		return
+	}
+	issueType := issueTypeOf(p, jira.IssueType{ID: r.PathValue("id")})
+	if issueType == nil {
+		writeError(w, http.StatusNotFound, "Issue type %s does not exist in %s", r.PathValue("id"), p.Key)
+		return
+	}
+
+	fields := []map[string]interface{}{}
+	for _, f := range append(append([]jira.Field{}, systemFields...), s.fields...) {
+		if f.ID == "parent" && !issueType.Subtask && !p.teamManaged {
+			continue
+		}
+		if s.hidden[hiddenKey(issueType.Name, f.ID)] {
+			continue
+		}
+		fields = append(fields, map[string]interface{}{
+			"fieldId":         f.ID,
+			"name":            f.Name,
+			"required":        f.ID == "summary" || f.ID == "issuetype" || f.ID == "project",
+			"hasDefaultValue": false,
+			"schema":          f.Schema,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"startAt":    0,
+		"maxResults": len(fields),
+		"total":      len(fields),
+		"fields":     fields,
+	})
+}
+
+func (s *Server) createIssue(w http.ResponseWriter, r *http.Request) {
+	var issue jira.Issue
+	if err := json.NewDecoder(r.Body).Decode(&issue); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.store(issue)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	s.created = append(s.created, stored.Key)
+	writeJSON(w, http.StatusCreated, map[string]string{
+		"id":   stored.ID,
+		"key":  stored.Key,
+		"self": stored.Self,
+	})
+}
+
+func (s *Server) getIssue(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	issue := s.issue(r.PathValue("key"))
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.view(issue))
+}
+
+// view returns an issue with its sub-tasks and links filled in.
+func (s *Server) view(issue *jira.Issue) jira.Issue {
+	v := copyIssue(issue)
+	v.Fields.Subtasks = nil
+	v.Fields.IssueLinks = nil
+
+	for _, child := range s.issues {
+		if child.Fields.Parent != nil && child.Fields.Parent.Key == issue.Key && child.Fields.Type.Subtask {
+			v.Fields.Subtasks = append(v.Fields.Subtasks, &jira.Subtasks{
+				ID:     child.ID,
+				Key:    child.Key,
+				Self:   child.Self,
+				Fields: *copyIssue(child).Fields,
+			})
+		}
+	}
+
+	// like Jira, a link shows the issue on the other end of it
+	for _, link := range s.links {
+		switch {
+		case link.InwardIssue.Key == issue.Key:
+			v.Fields.IssueLinks = append(v.Fields.IssueLinks, &jira.IssueLink{
+				ID:           link.ID,
+				Type:         link.Type,
+				OutwardIssue: s.linkedIssue(link.OutwardIssue.Key),
+			})
+		case link.OutwardIssue.Key == issue.Key:
+			v.Fields.IssueLinks = append(v.Fields.IssueLinks, &jira.IssueLink{
+				ID:          link.ID,
+				Type:        link.Type,
+				InwardIssue: s.linkedIssue(link.InwardIssue.Key),
+			})
+		}
+	}
+	return v
+}
+
+func (s *Server) linkedIssue(key string) *jira.Issue {
+	issue := s.issue(key)
+	return &jira.Issue{
+		ID:   issue.ID,
+		Key:  issue.Key,
+		Self: issue.Self,
+		Fields: &jira.IssueFields{
+			Summary: issue.Fields.Summary,
+			Type:    issue.Fields.Type,
+			Status:  issue.Fields.Status,
+		},
+	}
+}
+
+// getComment returns a comment of an issue. Tests add comments with the
+// issues they pass to AddIssue.
+func (s *Server) getComment(w http.ResponseWriter, r *http.Request) {
+	commentID, found := strings.CutPrefix(r.PathValue("rest"), "comment/")
+	if !found || commentID == "" || strings.Contains(commentID, "/") {
+		writeError(w, http.StatusNotFound, "jiratest does not implement %s %s", r.Method, r.URL.Path)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	issue := s.issue(r.PathValue("key"))
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.")
+		return
+	}
+	if issue.Fields.Comments != nil {
+		for _, c := range issue.Fields.Comments.Comments {
+			if c.ID == commentID {
+				writeJSON(w, http.StatusOK, c)
+				return
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, "Can not find a comment for the id: %s.", commentID)
+}
+
+func (s *Server) addWatcher(w http.ResponseWriter, r *http.Request) {
+	var user string
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	issue := s.issue(r.PathValue("key"))
+	if issue == nil {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.")
+		return
+	}
+	s.watchers[issue.Key] = append(s.watchers[issue.Key], user)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) createLink(w http.ResponseWriter, r *http.Request) {
+	var link jira.IssueLink
+	if err := json.NewDecoder(r.Body).Decode(&link); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if link.Type.Name == "" {
+		writeError(w, http.StatusBadRequest, "link type is required")
+		return
+	}
+	for _, end := range []*jira.Issue{link.InwardIssue, link.OutwardIssue} {
+		if end == nil || s.issue(end.Key) == nil {
+			writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.")
+			return
+		}
+	}
+
+	link.ID = strconv.Itoa(len(s.links) + 1)
+	link.InwardIssue = &jira.Issue{Key: s.issue(link.InwardIssue.Key).Key}
+	link.OutwardIssue = &jira.Issue{Key: s.issue(link.OutwardIssue.Key).Key}
+	s.links = append(s.links, link)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// search implements the enhanced JQL search, which pages with
+// nextPageToken. The tokens are plain offsets.
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	match, err := s.parseJQL(r.URL.Query().Get("jql"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	start := 0
+	if token := r.URL.Query().Get("nextPageToken"); token != "" {
+		if start, err = strconv.Atoi(token); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid nextPageToken %q", token)
+			return
+		}
+	}
+
+	var found []*jira.Issue
+	for _, issue := range s.issues {
+		if match(issue) {
+			found = append(found, issue)
+		}
+	}
+
+	issues, end := s.page(found, start, r.URL.Query().Get("maxResults"))
+	body := map[string]interface{}{
+		"issues": issues,
+		"isLast": end >= len(found),
+	}
+	if end < len(found) {
+		body["nextPageToken"] = strconv.Itoa(end)
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// epicIssues implements the agile epic issues endpoint, which returns the
+// issues in an epic through either the parent or the Epic Link field.
+func (s *Server) epicIssues(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	epic := s.issue(r.PathValue("key"))
+	if epic == nil {
+		writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.")
+		return
+	}
+
+	epicLink := s.fieldID("Epic Link")
+	var found []*jira.Issue
+	for _, issue := range s.issues {
+		if (issue.Fields.Parent != nil && issue.Fields.Parent.Key == epic.Key && !issue.Fields.Type.Subtask) ||
+			(epicLink != "" && fieldValue(issue, epicLink) == epic.Key) {
+			found = append(found, issue)
+		}
+	}
+
+	start, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+	issues, _ := s.page(found, start, r.URL.Query().Get("maxResults"))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"startAt":    start,
+		"maxResults": len(issues),
+		"total":      len(found),
+		"issues":     issues,
+	})
+}
+
+// page returns the issues of a page and the offset of the next one.
+func (s *Server) page(found []*jira.Issue, start int, maxResults string) ([]jira.Issue, int) {
+	size := s.PageSize
+	if n, err := strconv.Atoi(maxResults); err == nil && n > 0 && n < size {
+		size = n
+	}
+
+	issues := []jira.Issue{}
+	end := start
+	for ; end < len(found) && end < start+size; end++ {
+		issues = append(issues, s.view(found[end]))
+	}
+	return issues, end
+}
@@ -0,0 +1,187 @@
+package jiratest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+var (
+	orderByRe = regexp.MustCompile(`(?i)\s+order\s+by\s+.*$`)
+	orRe      = regexp.MustCompile(`(?i)\s+or\s+`)
+	andRe     = regexp.MustCompile(`(?i)\s+and\s+`)
+	clauseRe  = regexp.MustCompile(`(?i)^("[^"]+"|[\w.\[\]]+)\s*(!=|=|~|not\s+in|in)\s*(.+)$`)
+)
+
+// parseJQL supports the subset of JQL gojitzu and its tests use: clauses of
+// field = value, field != value, field ~ text, field in (a, b) and
+// field not in (a, b), joined by AND and OR without parentheses. AND binds
+// tighter than OR and ORDER BY is ignored.
+func (s *Server) parseJQL(jql string) (func(*jira.Issue) bool, error) {
+	jql = strings.TrimSpace(orderByRe.ReplaceAllString(jql, ""))
+	if jql == "" {
+		return func(*jira.Issue) bool { return true }, nil
+	}
+
+	var groups [][]func(*jira.Issue) bool
+	for _, or := range orRe.Split(jql, -1) {
+		var all []func(*jira.Issue) bool
+		for _, clause := range andRe.Split(or, -1) {
+			match, err := s.parseClause(strings.TrimSpace(clause))
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, match)
+		}
+		groups = append(groups, all)
+	}
+
+	return func(issue *jira.Issue) bool {
+	next:
+		for _, all := range groups {
+			for _, match := range all {
+				if !match(issue) {
+					continue next
+				}
+			}
+			return true
+		}
+		return false
+	}, nil
+}
+
+func (s *Server) parseClause(clause string) (func(*jira.Issue) bool, error) {
+	if strings.ContainsAny(clause, "()") && !strings.Contains(strings.ToLower(clause), " in ") {
+		return nil, fmt.Errorf("jiratest does not support parentheses in JQL: %s", clause)
+	}
+	m := clauseRe.FindStringSubmatch(clause)
+	if m == nil {
+		return nil, fmt.Errorf("unable to parse JQL clause %q", clause)
+	}
+	field, op, value := unquote(m[1]), strings.ToLower(strings.Join(strings.Fields(m[2]), " ")), m[3]
+
+	get, err := s.fieldGetter(field)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "=", "!=":
+		want := unquote(strings.TrimSpace(value))
+		return func(issue *jira.Issue) bool {
+			return containsFold(get(issue), want) == (op == "=")
+		}, nil
+	case "~":
+		want := strings.ToLower(unquote(strings.TrimSpace(value)))
+		return func(issue *jira.Issue) bool {
+			for _, v := range get(issue) {
+				if strings.Contains(strings.ToLower(v), want) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	default:
+		value = strings.TrimSpace(value)
+		if !strings.HasPrefix(value, "(") || !strings.HasSuffix(value, ")") {
+			return nil, fmt.Errorf("expected a list after %s in %q", op, clause)
+		}
+		var want []string
+		for _, v := range strings.Split(value[1:len(value)-1], ",") {
+			want = append(want, unquote(strings.TrimSpace(v)))
+		}
+		return func(issue *jira.Issue) bool {
+			found := false
+			for _, w := range want {
+				found = found || containsFold(get(issue), w)
+			}
+			return found == (op == "in")
+		}, nil
+	}
+}
+
+// fieldGetter returns the values of a JQL field of an issue.
+func (s *Server) fieldGetter(field string) (func(*jira.Issue) []string, error) {
+	switch strings.ToLower(field) {
+	case "project":
+		return func(i *jira.Issue) []string { return []string{i.Fields.Project.Key, i.Fields.Project.ID} }, nil
+	case "key", "issuekey", "id":
+		return func(i *jira.Issue) []string { return []string{i.Key, i.ID} }, nil
+	case "parent":
+		return func(i *jira.Issue) []string {
+			if i.Fields.Parent == nil {
+				return nil
+			}
+			return []string{i.Fields.Parent.Key, i.Fields.Parent.ID}
+		}, nil
+	case "type", "issuetype":
+		return func(i *jira.Issue) []string { return []string{i.Fields.Type.Name, i.Fields.Type.ID} }, nil
+	case "status":
+		return func(i *jira.Issue) []string {
+			if i.Fields.Status == nil {
+				return nil
+			}
+			return []string{i.Fields.Status.Name}
+		}, nil
+	case "summary", "text":
+		return func(i *jira.Issue) []string { return []string{i.Fields.Summary} }, nil
+	case "labels":
+		return func(i *jira.Issue) []string { return i.Fields.Labels }, nil
+	}
+
+	id := field
+	if m := regexp.MustCompile(`^cf\[(\d+)\]$`).FindStringSubmatch(field); m != nil {
+		id = "customfield_" + m[1]
+	} else if found := s.fieldID(field); found != "" {
+		id = found
+	} else if !strings.HasPrefix(field, "customfield_") {
+		return nil, fmt.Errorf("field %q does not exist", field)
+	}
+	return func(i *jira.Issue) []string { return []string{fieldValue(i, id)} }, nil
+}
+
+// fieldID finds a custom field by name.
+func (s *Server) fieldID(name string) string {
+	for _, f := range s.fields {
+		if strings.EqualFold(f.Name, name) {
+			return f.ID
+		}
+	}
+	return ""
+}
+
+// fieldValue returns a custom field value as a string. Option and issue
+// values are objects, which are reduced to their key, value or name.
+func fieldValue(issue *jira.Issue, id string) string {
+	switch v := issue.Fields.Unknowns[id].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}:
+		for _, k := range []string{"key", "value", "name", "id"} {
+			if s, ok := v[k].(string); ok {
+				return s
+			}
+		}
+	}
+	return fmt.Sprint(issue.Fields.Unknowns[id])
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func containsFold(values []string, want string) bool {
+	for _, v := range values {
+		if v != "" && strings.EqualFold(v, want) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,307 @@
+// Package jiratest runs an in-memory Jira server for tests. It implements the
+// REST endpoints gojitzu uses, keeps the projects, fields, users, issues and
+// links in memory and records every request so tests can assert on them.
+package jiratest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+// DefaultIssueTypes are given to projects added without issue types.
+var DefaultIssueTypes = []jira.IssueType{
+	{ID: "10000", Name: "Epic"},
+	{ID: "10001", Name: "Task"},
+	{ID: "10002", Name: "Sub-task", Subtask: true},
+	{ID: "10003", Name: "Bug"},
+}
+
+// ClassicEpicFields are the custom fields company-managed projects use for
+// epics.
+var ClassicEpicFields = []jira.Field{
+	{ID: "customfield_10014", Name: "Epic Link", Custom: true, Schema: jira.FieldSchema{Type: "any", Custom: "com.pyxis.greenhopper.jira:gh-epic-link"}},
+	{ID: "customfield_10011", Name: "Epic Name", Custom: true, Schema: jira.FieldSchema{Type: "string", Custom: "com.pyxis.greenhopper.jira:gh-epic-label"}},
+	{ID: "customfield_10017", Name: "Epic Colour", Custom: true, Schema: jira.FieldSchema{Type: "string", Custom: "com.pyxis.greenhopper.jira:gh-epic-color"}},
+}
+
+// Request is a request the server received.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Body   []byte
+}
+
+// Server is an in-memory Jira server.
+type Server struct {
+	*httptest.Server
+
+	// Cloud makes the server report a Jira Cloud deployment.
+	Cloud bool
+	// PageSize caps the number of issues of a search page, 50 by default.
+	PageSize int
+
+	mu       sync.Mutex
+	projects []*project
+	fields   []jira.Field
+	users    []jira.User
+	issues   []*jira.Issue
+	created  []string
+	links    []jira.IssueLink
+	watchers map[string][]string
+	hidden   map[string]bool
+	requests []Request
+	lastID   int
+	lastKeys map[string]int
+}
+
+type project struct {
+	jira.Project
+	teamManaged bool
+}
+
+// NewServer starts a server that is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	s := &Server{
+		PageSize: 50,
+		watchers: map[string][]string{},
+		hidden:   map[string]bool{},
+		lastID:   10000,
+		lastKeys: map[string]int{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rest/api/2/serverInfo", s.serverInfo)
+	mux.HandleFunc("GET /rest/api/2/myself", s.myself)
+	mux.HandleFunc("GET /rest/api/2/user/search", s.searchUsers)
+	mux.HandleFunc("GET /rest/api/2/project", s.listProjects)
+	mux.HandleFunc("GET /rest/api/2/project/{key}", s.getProject)
+	mux.HandleFunc("GET /rest/api/2/field", s.listFields)
+	mux.HandleFunc("GET /rest/api/2/issue/createmeta/{key}/issuetypes", s.createMetaTypes)
+	mux.HandleFunc("GET /rest/api/2/issue/createmeta/{key}/issuetypes/{id}", s.createMetaFields)
+	mux.HandleFunc("POST /rest/api/2/issue", s.createIssue)
+	mux.HandleFunc("GET /rest/api/2/issue/{key}", s.getIssue)
+	// comment/{id} as its own pattern would conflict with createmeta
+	mux.HandleFunc("GET /rest/api/2/issue/{key}/{rest...}", s.getComment)
+	mux.HandleFunc("POST /rest/api/2/issue/{key}/watchers", s.addWatcher)
+	mux.HandleFunc("POST /rest/api/2/issueLink", s.createLink)
+	mux.HandleFunc("GET /rest/api/2/search/jql", s.search)
+	mux.HandleFunc("GET /rest/agile/1.0/epic/{key}/issue", s.epicIssues)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "jiratest does not implement %s %s", r.Method, r.URL.Path)
+	})
+
+	s.Server = httptest.NewServer(s.record(mux))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// record keeps a copy of every request before handing it on.
+func (s *Server) record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(strings.NewReader(string(body)))
+
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query(), Body: body})
+		s.mu.Unlock()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AddProject adds a company-managed project. Projects without issue types get
+// DefaultIssueTypes.
+func (s *Server) AddProject(p jira.Project) {
+	s.addProject(p, false)
+}
+
+// AddTeamManagedProject adds a team-managed (next-gen) project.
+func (s *Server) AddTeamManagedProject(p jira.Project) {
+	s.addProject(p, true)
+}
+
+func (s *Server) addProject(p jira.Project, teamManaged bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p.ID == "" {
+		p.ID = strconv.Itoa(len(s.projects) + 1)
+	}
+	if p.Name == "" {
+		p.Name = p.Key
+	}
+	if p.IssueTypes == nil {
+		p.IssueTypes = append([]jira.IssueType(nil), DefaultIssueTypes...)
+	}
+	s.projects = append(s.projects, &project{Project: p, teamManaged: teamManaged})
+}
+
+// AddField adds fields to the field list.
+func (s *Server) AddField(fields ...jira.Field) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fields = append(s.fields, fields...)
+}
+
+// AddUser adds users to the user search. The first user is the one the
+// client is authenticated as.
+func (s *Server) AddUser(users ...jira.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = append(s.users, users...)
+}
+
+// HideField removes a field from the create screen of an issue type, as Cloud
+// projects that dropped Epic Name from epics do. Issues of that type can not
+// set the field.
+func (s *Server) HideField(issueType string, fieldID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hidden[hiddenKey(issueType, fieldID)] = true
+}
+
+func hiddenKey(issueType string, fieldID string) string {
+	return strings.ToLower(issueType) + "/" + fieldID
+}
+
+// AddIssue adds an existing issue to a project and returns it with its key.
+func (s *Server) AddIssue(issue jira.Issue) (jira.Issue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.store(issue)
+	if err != nil {
+		return jira.Issue{}, err
+	}
+	return copyIssue(stored), nil
+}
+
+// store validates an issue like Jira does and saves it under a new key.
+func (s *Server) store(issue jira.Issue) (*jira.Issue, error) {
+	issue = copyIssue(&issue)
+	if issue.Fields == nil {
+		return nil, fmt.Errorf("fields are required")
+	}
+	fields := issue.Fields
+
+	p := s.project(fields.Project.Key)
+	if p == nil && fields.Project.ID != "" {
+		p = s.projectByID(fields.Project.ID)
+	}
+	if p == nil {
+		return nil, fmt.Errorf("project: valid project is required")
+	}
+	if fields.Summary == "" {
+		return nil, fmt.Errorf("summary: You must specify a summary of the issue.")
+	}
+
+	issueType := issueTypeOf(p, fields.Type)
+	if issueType == nil {
+		return nil, fmt.Errorf("issuetype: valid issue type is required")
+	}
+	for fieldID := range fields.Unknowns {
+		if s.hidden[hiddenKey(issueType.Name, fieldID)] {
+			return nil, fmt.Errorf("%s: Field '%s' cannot be set. It is not on the appropriate screen, or unknown.", fieldID, fieldID)
+		}
+	}
+	if fields.Parent != nil {
+		parent := s.issue(fields.Parent.Key)
+		if parent == nil && fields.Parent.ID != "" {
+			parent = s.issue(fields.Parent.ID)
+		}
+		if parent == nil {
+			return nil, fmt.Errorf("parent: could not find issue %s%s", fields.Parent.Key, fields.Parent.ID)
+		}
+		fields.Parent = &jira.Parent{ID: parent.ID, Key: parent.Key}
+	} else if issueType.Subtask {
+		return nil, fmt.Errorf("parent: sub-tasks need a parent issue")
+	}
+
+	s.lastID++
+	s.lastKeys[p.Key]++
+	issue.ID = strconv.Itoa(s.lastID)
+	issue.Key = fmt.Sprintf("%s-%d", p.Key, s.lastKeys[p.Key])
+	issue.Self = s.URL + "/rest/api/2/issue/" + issue.ID
+	fields.Project = jira.Project{ID: p.ID, Key: p.Key, Name: p.Name}
+	fields.Type = *issueType
+	if fields.Status == nil {
+		fields.Status = &jira.Status{Name: "To Do"}
+	}
+
+	s.issues = append(s.issues, &issue)
+	return &issue, nil
+}
+
+func issueTypeOf(p *project, t jira.IssueType) *jira.IssueType {
+	for i, issueType := range p.IssueTypes {
+		if (t.ID != "" && issueType.ID == t.ID) || (t.ID == "" && t.Name != "" && strings.EqualFold(issueType.Name, t.Name)) {
+			return &p.IssueTypes[i]
+		}
+	}
+	return nil
+}
+
+func (s *Server) project(key string) *project {
+	for _, p := range s.projects {
+		if strings.EqualFold(p.Key, key) {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Server) projectByID(id string) *project {
+	for _, p := range s.projects {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// issue finds an issue by key or id.
+func (s *Server) issue(key string) *jira.Issue {
+	for _, issue := range s.issues {
+		if strings.EqualFold(issue.Key, key) || issue.ID == key {
+			return issue
+		}
+	}
+	return nil
+}
+
+// copyIssue copies an issue through JSON, the way a client would see it.
+func copyIssue(issue *jira.Issue) jira.Issue {
+	data, err := json.Marshal(issue)
+	if err != nil {
+		panic(err)
+	}
+	var c jira.Issue
+	if err := json.Unmarshal(data, &c); err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	writeJSON(w, status, map[string]interface{}{
+		"errorMessages": []string{fmt.Sprintf(format, args...)},
+		"errors":        map[string]string{},
+	})
+}
@@ -0,0 +1,199 @@
+package jiratest
+
+import (
+	"testing"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+func newClient(t *testing.T, s *Server) *jira.Client {
+	t.Helper()
+	tp := jira.BasicAuthTransport{Username: "user", Password: "token"}
+	client, err := jira.NewClient(tp.Client(), s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func create(t *testing.T, client *jira.Client, fields *jira.IssueFields) *jira.Issue {
+	t.Helper()
+	issue, _, err := client.Issue.Create(&jira.Issue{Fields: fields})
+	if err != nil {
+		t.Fatalf("create %q: %v", fields.Summary, err)
+	}
+	return issue
+}
+
+func TestCreateAndGetIssue(t *testing.T) {
+	s := NewServer(t)
+	s.AddProject(jira.Project{Key: "OPS"})
+	client := newClient(t, s)
+
+	parent := create(t, client, &jira.IssueFields{
+		Project: jira.Project{Key: "OPS"},
+		Type:    jira.IssueType{Name: "Task"},
+		Summary: "Parent",
+	})
+	if parent.Key != "OPS-1" {
+		t.Errorf("got key %s, want OPS-1", parent.Key)
+	}
+	create(t, client, &jira.IssueFields{
+		Project: jira.Project{Key: "OPS"},
+		Type:    jira.IssueType{ID: "10002"},
+		Summary: "Child",
+		Parent:  &jira.Parent{Key: parent.Key},
+	})
+
+	got, _, err := client.Issue.Get(parent.Key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Fields.Type.Name != "Task" || len(got.Fields.Subtasks) != 1 || got.Fields.Subtasks[0].Key != "OPS-2" {
+		t.Errorf("unexpected issue %+v", got.Fields)
+	}
+	s.AssertCreated(t, "Parent", "Child")
+	s.AssertRequested(t, "GET", "/rest/api/2/issue/OPS-1")
+}
+
+func TestCreateIssueErrors(t *testing.T) {
+	s := NewServer(t)
+	s.AddProject(jira.Project{Key: "OPS"})
+	client := newClient(t, s)
+
+	for name, fields := range map[string]*jira.IssueFields{
+		"unknown project":    {Project: jira.Project{Key: "NOPE"}, Type: jira.IssueType{Name: "Task"}, Summary: "x"},
+		"unknown type":       {Project: jira.Project{Key: "OPS"}, Type: jira.IssueType{Name: "Story"}, Summary: "x"},
+		"missing summary":    {Project: jira.Project{Key: "OPS"}, Type: jira.IssueType{Name: "Task"}},
+		"sub-task no parent": {Project: jira.Project{Key: "OPS"}, Type: jira.IssueType{Name: "Sub-task"}, Summary: "x"},
+	} {
+		if _, _, err := client.Issue.Create(&jira.Issue{Fields: fields}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if len(s.Created()) != 0 {
+		t.Errorf("created %d issues", len(s.Created()))
+	}
+}
+
+func TestSearchPages(t *testing.T) {
+	s := NewServer(t)
+	s.PageSize = 2
+	s.AddProject(jira.Project{Key: "OPS"})
+	s.AddProject(jira.Project{Key: "DEV"})
+	for _, key := range []string{"OPS", "DEV", "OPS", "OPS", "OPS"} {
+		if _, err := s.AddIssue(jira.Issue{Fields: &jira.IssueFields{
+			Project: jira.Project{Key: key},
+			Type:    jira.IssueType{Name: "Task"},
+			Summary: key + " task",
+		}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client := newClient(t, s)
+
+	var keys []string
+	opt := &jira.SearchOptionsV2{MaxResults: 1000}
+	for pages := 1; ; pages++ {
+		issues, resp, err := client.Issue.SearchV2JQL("project = OPS ORDER BY key", opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, issue := range issues {
+			keys = append(keys, issue.Key)
+		}
+		if resp.IsLast {
+			if pages != 2 {
+				t.Errorf("got %d pages, want 2", pages)
+			}
+			break
+		}
+		opt.NextPageToken = resp.NextPageToken
+	}
+	if len(keys) != 4 || keys[0] != "OPS-1" || keys[3] != "OPS-4" {
+		t.Errorf("got %v", keys)
+	}
+}
+
+func TestJQL(t *testing.T) {
+	s := NewServer(t)
+	s.AddProject(jira.Project{Key: "OPS"})
+	s.AddField(ClassicEpicFields...)
+	epic, _ := s.AddIssue(jira.Issue{Fields: &jira.IssueFields{Project: jira.Project{Key: "OPS"}, Type: jira.IssueType{Name: "Epic"}, Summary: "Epic"}})
+	s.AddIssue(jira.Issue{Fields: &jira.IssueFields{
+		Project:  jira.Project{Key: "OPS"},
+		Type:     jira.IssueType{Name: "Task"},
+		Summary:  "Linked",
+		Labels:   []string{"infra"},
+		Unknowns: map[string]interface{}{"customfield_10014": epic.Key},
+	}})
+	s.AddIssue(jira.Issue{Fields: &jira.IssueFields{Project: jira.Project{Key: "OPS"}, Type: jira.IssueType{Name: "Task"}, Summary: "Child", Parent: &jira.Parent{Key: epic.Key}}})
+
+	for jql, want := range map[string]int{
+		"":                                      3,
+		`parent = OPS-1 OR "Epic Link" = OPS-1`: 2,
+		"cf[10014] = OPS-1":                     1,
+		"type = Task AND labels in (infra, x)":  1,
+		"type != Epic":                          2,
+		`summary ~ "link"`:                      1,
+		"key not in (OPS-1, OPS-2)":             1,
+	} {
+		match, err := s.parseJQL(jql)
+		if err != nil {
+			t.Errorf("%q: %v", jql, err)
+			continue
+		}
+		got := 0
+		for _, issue := range s.issues {
+			if match(issue) {
+				got++
+			}
+		}
+		if got != want {
+			t.Errorf("%q matched %d issues, want %d", jql, got, want)
+		}
+	}
+
+	for _, jql := range []string{"(project = OPS)", "nope = 1", "project"} {
+		if _, err := s.parseJQL(jql); err == nil {
+			t.Errorf("%q: expected an error", jql)
+		}
+	}
+}
+
+func TestLinksAndEpicIssues(t *testing.T) {
+	s := NewServer(t)
+	s.AddTeamManagedProject(jira.Project{Key: "OPS"})
+	client := newClient(t, s)
+
+	epic := create(t, client, &jira.IssueFields{Project: jira.Project{Key: "OPS"}, Type: jira.IssueType{Name: "Epic"}, Summary: "Epic"})
+	a := create(t, client, &jira.IssueFields{Project: jira.Project{Key: "OPS"}, Type: jira.IssueType{Name: "Task"}, Summary: "A", Parent: &jira.Parent{Key: epic.Key}})
+	b := create(t, client, &jira.IssueFields{Project: jira.Project{Key: "OPS"}, Type: jira.IssueType{Name: "Task"}, Summary: "B", Parent: &jira.Parent{Key: epic.Key}})
+
+	_, err := client.Issue.AddLink(&jira.IssueLink{
+		Type:         jira.IssueLinkType{Name: "Blocks"},
+		InwardIssue:  &jira.Issue{Key: a.Key},
+		OutwardIssue: &jira.Issue{Key: b.Key},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AssertLinked(t, "Blocks", a.Key, b.Key)
+
+	got := s.RequireIssue(t, a.Key)
+	if len(got.Fields.IssueLinks) != 1 || got.Fields.IssueLinks[0].OutwardIssue.Key != b.Key {
+		t.Errorf("unexpected links %+v", got.Fields.IssueLinks)
+	}
+
+	req, _ := client.NewRequest("GET", "rest/agile/1.0/epic/"+epic.Key+"/issue", nil)
+	result := struct {
+		Total  int          `json:"total"`
+		Issues []jira.Issue `json:"issues"`
+	}{}
+	if _, err := client.Do(req, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 2 || result.Issues[0].Key != a.Key {
+		t.Errorf("got %d epic issues", result.Total)
+	}
+}
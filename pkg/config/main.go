@@ -1,10 +1,60 @@
 package config
 
+import "strings"
+
 type CustomField struct {
 	JiraField string `json:"JiraField" yaml:"jira_field"`
 	Name      string `json:"Name" yaml:"name"`
 }
 
+// TemplateSource is a git repository or a .tar.gz/.zip bundle of templates,
+// referenced in templates and flags as NAME:path/to/template.yaml
+type TemplateSource struct {
+	Name string `json:"Name" yaml:"name"`
+	// URL is a git url or the path or url of an archive
+	URL string `json:"URL" yaml:"url"`
+	// Ref is the git branch, tag or commit to use, the default branch when empty
+	Ref string `json:"Ref" yaml:"ref"`
+	// Path is the directory inside the source holding the templates
+	Path string `json:"Path" yaml:"path"`
+}
+
 type ConfigMap struct {
 	CustomFields []CustomField `json:"CustomFields" yaml:"custom_fields"`
+	// Holidays are skipped when counting business days, as YYYY-MM-DD
+	Holidays        []string         `json:"Holidays" yaml:"holidays"`
+	TemplateSources []TemplateSource `json:"TemplateSources" yaml:"template_sources"`
+	// IssueTypes maps the issue types gojitzu uses (task, sub-task, epic) and
+	// those named in templates to the name or id of a project's issue type,
+	// per project key. The "*" entry applies to every project.
+	IssueTypes map[string]map[string]string `json:"IssueTypes" yaml:"issue_types"`
+}
+
+// IssueType returns the issue type name or id a project uses for name, or
+// name itself when it is not mapped.
+func (c ConfigMap) IssueType(projectKey string, name string) string {
+	for _, key := range []string{projectKey, "*"} {
+		for from, to := range c.IssueTypes[key] {
+			if strings.EqualFold(from, name) {
+				return to
+			}
+		}
+	}
+	return name
+}
+
+// CustomFieldID resolves a configured custom field name to its Jira field id.
+// Jira field ids (customfield_10010) are returned as is.
+func (c ConfigMap) CustomFieldID(name string) (string, bool) {
+	for _, field := range c.CustomFields {
+		if strings.EqualFold(field.Name, name) || field.JiraField == name {
+			return field.JiraField, true
+		}
+	}
+
+	if strings.HasPrefix(name, "customfield_") {
+		return name, true
+	}
+
+	return "", false
 }